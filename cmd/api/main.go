@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
 	"encoding/gob"
 	"fmt"
 	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/alexedwards/scs/pgxstore"
@@ -17,6 +19,8 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"github.com/oThinas/bid/internal/api"
+	"github.com/oThinas/bid/internal/mailer"
+	"github.com/oThinas/bid/internal/payments"
 	"github.com/oThinas/bid/internal/services"
 )
 
@@ -56,21 +60,80 @@ func main() {
 	sessionManager.Store = pgxstore.New(pool)
 	sessionManager.Lifetime = 24 * time.Hour
 	sessionManager.Cookie.HttpOnly = true
+	sessionManager.Cookie.Secure = os.Getenv("COOKIE_SECURE") == "true"
+	sessionManager.Cookie.SameSite = parseSameSite(os.Getenv("COOKIE_SAMESITE"))
+	if sessionManager.Cookie.SameSite == http.SameSiteNoneMode && !sessionManager.Cookie.Secure {
+		panic("COOKIE_SAMESITE=none requires COOKIE_SECURE=true")
+	}
+
+	slowConsumerPolicy, err := services.ParseSlowConsumerPolicy(os.Getenv("SLOW_CONSUMER_POLICY"))
+	if err != nil {
+		panic(err)
+	}
+
+	mailTemplates, err := mailer.NewTemplates(envOr("MAIL_LOCALE", "en"))
+	if err != nil {
+		panic(err)
+	}
+
+	mailQueue := mailer.NewQueue(parseMailer())
+	paymentProvider := parsePaymentProvider()
+	fakePayments, _ := paymentProvider.(*payments.FakeProvider)
+
+	productService := services.NewProductService(pool)
+	bidsService := services.NewBidsService(pool)
+	auctionBus := services.NewAuctionBus(pool)
+	settlementService := services.NewSettlementService(pool)
+	webhookService := services.NewWebhookService(pool)
+	notificationHub := services.NewNotificationHub(auctionBus)
+	notificationService := services.NewNotificationService(pool, mailQueue, mailTemplates, notificationHub)
+	reminderService := services.NewReminderService(pool)
+	deadlineService := services.NewDeadlineService(pool)
+	paymentService := services.NewPaymentService(pool, paymentProvider)
+	auctionEventsService := services.NewAuctionEventsService(pool)
 
 	api := api.Api{
-		Router:   chi.NewMux(),
-		Sessions: sessionManager,
-		WsUpgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool { return true },
-		},
-		UserService:    services.NewUserService(pool),
-		ProductService: services.NewProductService(pool),
-		BidsService:    services.NewBidsService(pool),
+		Router:              chi.NewMux(),
+		Sessions:            sessionManager,
+		WsUpgrader:          websocket.Upgrader{Subprotocols: services.Subprotocols},
+		AllowedOrigins:      parseList(os.Getenv("ALLOWED_ORIGINS")),
+		UserService:         services.NewUserService(pool),
+		TokenService:        services.NewTokenService(pool),
+		SessionService:      services.NewSessionService(pool),
+		LoginThrottle:       services.NewLoginThrottleService(pool),
+		ProductService:      productService,
+		BidsService:         bidsService,
+		OrderService:        services.NewOrderService(pool),
+		PaymentService:      paymentService,
+		FakePayments:        fakePayments,
+		SecondChanceService: services.NewSecondChanceService(pool),
+		LedgerService:       services.NewLedgerService(pool),
+		FeeService:          services.NewFeeService(pool),
+		WebhookService:      webhookService,
+		NotificationService: notificationService,
+		NotificationHub:     notificationHub,
 		AuctionLobby: services.AuctionLobby{
-			Rooms: make(map[uuid.UUID]*services.AuctionRoom),
+			Rooms:              make(map[uuid.UUID]*services.AuctionRoom),
+			SlowConsumerPolicy: slowConsumerPolicy,
+			ProductService:     &productService,
+			BidsService:        &bidsService,
+			EventsService:      &auctionEventsService,
+			SettlementService:  &settlementService,
+			Bus:                auctionBus,
+			Notifications:      notificationHub,
 		},
 	}
 
+	go auctionBus.Listen(ctx, &api.AuctionLobby)
+	go settlementService.Run(ctx)
+	go services.NewOutboxDispatcher(pool, services.LogSink{}, &webhookService, &notificationService).Run(ctx)
+	go webhookService.Run(ctx)
+	go reminderService.Run(ctx)
+	go deadlineService.Run(ctx)
+	go paymentService.Run(ctx)
+	go mailQueue.Run(ctx)
+
+	api.WsUpgrader.CheckOrigin = api.CheckOrigin
 	api.BindRoutes()
 
 	fmt.Println("Server started on port :8080")
@@ -78,3 +141,84 @@ func main() {
 		panic(err)
 	}
 }
+
+func parseSameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "", "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		panic("invalid COOKIE_SAMESITE: " + value)
+	}
+}
+
+// parseMailer returns the mail driver picked with MAIL_DRIVER.
+func parseMailer() mailer.Mailer {
+	from := envOr("MAIL_FROM", "Bid <no-reply@localhost>")
+
+	switch strings.ToLower(os.Getenv("MAIL_DRIVER")) {
+	case "", mailer.DriverLog:
+		return mailer.LogMailer{}
+	case mailer.DriverFile:
+		return mailer.FileMailer{Dir: envOr("MAIL_DIR", "mail"), From: from}
+	case mailer.DriverSMTP:
+		host := os.Getenv("SMTP_HOST")
+		if host == "" {
+			panic("MAIL_DRIVER=smtp requires SMTP_HOST")
+		}
+
+		return mailer.SMTPMailer{
+			Host:     host,
+			Port:     envOr("SMTP_PORT", "587"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	default:
+		panic("invalid MAIL_DRIVER: " + os.Getenv("MAIL_DRIVER"))
+	}
+}
+
+// parsePaymentProvider returns the payment provider picked with
+// PAYMENTS_DRIVER. The fake provider sends its webhooks to this server, at
+// PUBLIC_URL.
+func parsePaymentProvider() payments.Provider {
+	switch strings.ToLower(os.Getenv("PAYMENTS_DRIVER")) {
+	case "", payments.DriverFake:
+		secret := os.Getenv("PAYMENTS_WEBHOOK_SECRET")
+		if secret == "" {
+			secret = rand.Text()
+		}
+
+		publicURL := strings.TrimSuffix(envOr("PUBLIC_URL", "http://localhost:8080"), "/")
+		return payments.NewFakeProvider(
+			publicURL+api.PaymentWebhookPath,
+			publicURL+api.FakePaymentChallengePath,
+			secret,
+		)
+	default:
+		panic("invalid PAYMENTS_DRIVER: " + os.Getenv("PAYMENTS_DRIVER"))
+	}
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+func parseList(value string) []string {
+	var items []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
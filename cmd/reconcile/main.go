@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+	"github.com/oThinas/bid/internal/services"
+)
+
+// reconcile checks that every journal of the ledger sums to zero, exiting
+// with status 1 when one doesn't.
+func main() {
+	if err := godotenv.Load(); err != nil {
+		panic("Error loading .env file: " + err.Error())
+	}
+
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, fmt.Sprintf(
+		"user=%s password=%s host=%s port=%s dbname=%s",
+		os.Getenv("DATABASE_USER"),
+		os.Getenv("DATABASE_PASSWORD"),
+		os.Getenv("DATABASE_HOST"),
+		os.Getenv("DATABASE_PORT"),
+		os.Getenv("DATABASE_NAME"),
+	))
+	if err != nil {
+		panic(err)
+	}
+	defer pool.Close()
+
+	ledgerService := services.NewLedgerService(pool)
+	reconciliation, err := ledgerService.Reconcile(ctx)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("Journals: %d\n", reconciliation.Journals)
+	fmt.Printf("Postings: %d\n", reconciliation.Postings)
+	fmt.Printf("Total: %d cents\n", reconciliation.Total)
+
+	if reconciliation.Balanced() {
+		fmt.Println("The ledger is balanced")
+		return
+	}
+
+	for _, journal := range reconciliation.Unbalanced {
+		fmt.Printf(
+			"Unbalanced %s journal %s of %s: %d postings summing to %d cents\n",
+			journal.Kind,
+			journal.ID,
+			journal.ReferenceID,
+			journal.Postings,
+			journal.Total,
+		)
+	}
+
+	fmt.Println("The ledger is NOT balanced")
+	os.Exit(1)
+}
@@ -4,15 +4,33 @@ import (
 	"github.com/alexedwards/scs/v2"
 	"github.com/go-chi/chi/v5"
 	"github.com/gorilla/websocket"
+	"github.com/oThinas/bid/internal/payments"
 	"github.com/oThinas/bid/internal/services"
 )
 
 type Api struct {
-	Router         *chi.Mux
-	Sessions       *scs.SessionManager
-	WsUpgrader     websocket.Upgrader
-	UserService    services.UserService
-	ProductService services.ProductService
-	BidsService    services.BidsService
-	AuctionLobby   services.AuctionLobby
+	Router              *chi.Mux
+	Sessions            *scs.SessionManager
+	WsUpgrader          websocket.Upgrader
+	UserService         services.UserService
+	TokenService        services.TokenService
+	SessionService      services.SessionService
+	LoginThrottle       services.LoginThrottleService
+	ProductService      services.ProductService
+	BidsService         services.BidsService
+	OrderService        services.OrderService
+	PaymentService      services.PaymentService
+	SecondChanceService services.SecondChanceService
+	LedgerService       services.LedgerService
+	FeeService          services.FeeService
+	// FakePayments is the payment provider when it is the fake one, whose
+	// 3-D Secure challenges are then served by the API.
+	FakePayments        *payments.FakeProvider
+	WebhookService      services.WebhookService
+	NotificationService services.NotificationService
+	NotificationHub     *services.NotificationHub
+	AuctionLobby        services.AuctionLobby
+	// AllowedOrigins lists the browser origins allowed to make credentialed
+	// requests and open websockets, e.g. "https://bid.example.com".
+	AllowedOrigins []string
 }
@@ -2,10 +2,15 @@ package api
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
 	"github.com/oThinas/bid/internal/services"
 	"github.com/oThinas/bid/internal/utils"
 )
@@ -35,7 +40,15 @@ func (api *Api) handleSubscribeUserToAuction(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	userID, ok := api.Sessions.Get(r.Context(), AuthenticatedUserID).(uuid.UUID)
+	resumeFrom, err := resumeFrom(r)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid resume_from",
+		})
+		return
+	}
+
+	userID, ok := api.authenticatedUserID(r)
 	if !ok {
 		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
 			"error": "unexpected error, try again later.",
@@ -43,13 +56,46 @@ func (api *Api) handleSubscribeUserToAuction(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	api.AuctionLobby.Lock()
-	room, ok := api.AuctionLobby.Rooms[productID]
-	api.AuctionLobby.Unlock()
+	room, ok := api.openAuctionRoom(w, r, productID)
+	if !ok {
+		return
+	}
+
+	conn, err := api.WsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "could not upgrade connection to a websocket protocol",
+		})
+		return
+	}
+
+	client := services.NewClient(conn, &api.AuctionLobby, userID)
+	if token, ok := requestApiToken(r); ok {
+		client.ReadOnly = !services.HasScope(token, services.ScopeBidsWrite)
+	}
+
+	if err := client.SubscribeFrom(room, resumeFrom); err != nil {
+		conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, err.Error()),
+			time.Now().Add(services.WriteDeadLine),
+		)
+		conn.Close()
+		return
+	}
+
+	go client.ReadEventLoop()
+	go client.WriteEventLoop()
+}
 
+// handleSubscribeUserToAuctions opens a single websocket on which the client
+// subscribes to and unsubscribes from any number of auctions, up to
+// services.MaxSubscriptionsPerClient.
+func (api *Api) handleSubscribeUserToAuctions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := api.authenticatedUserID(r)
 	if !ok {
-		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
-			"error": "the auction has ended",
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected error, try again later.",
 		})
 		return
 	}
@@ -62,9 +108,166 @@ func (api *Api) handleSubscribeUserToAuction(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	client := services.NewClient(conn, room, userID)
+	client := services.NewClient(conn, &api.AuctionLobby, userID)
+	client.Multiplexed = true
+	if token, ok := requestApiToken(r); ok {
+		client.ReadOnly = !services.HasScope(token, services.ScopeBidsWrite)
+	}
 
-	room.Register <- client
 	go client.ReadEventLoop()
 	go client.WriteEventLoop()
 }
+
+// handleStreamAuctionEvents streams the room wide events of an auction as
+// server-sent events, for clients behind proxies that break websockets. Each
+// event id is the room sequence number, so a reconnecting EventSource resumes
+// through the Last-Event-ID header, see resumeFrom.
+func (api *Api) handleStreamAuctionEvents(w http.ResponseWriter, r *http.Request) {
+	productID, err := uuid.Parse(chi.URLParam(r, "productID"))
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	resumeFrom, err := resumeFrom(r)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid resume_from",
+		})
+		return
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected error, try again later.",
+		})
+		return
+	}
+
+	room, ok := api.openAuctionRoom(w, r, productID)
+	if !ok {
+		return
+	}
+
+	client := services.NewFeedClient(&api.AuctionLobby, userID)
+	defer client.Close()
+
+	if err := client.SubscribeFrom(room, resumeFrom); err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "the auction has ended",
+		})
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(services.FeedKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+
+		case <-client.Done():
+			return
+
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+
+		case message := <-client.Send:
+			if !writeFeedEvent(w, message) {
+				return
+			}
+
+		case message := <-client.Control:
+			for _, pending := range client.Pending() {
+				if !writeFeedEvent(w, pending) {
+					return
+				}
+			}
+
+			writeFeedEvent(w, message)
+			rc.Flush()
+			return
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeFeedEvent writes message as a server-sent event and reports whether
+// the stream is still writable.
+func writeFeedEvent(w http.ResponseWriter, message services.Message) bool {
+	data, err := services.MarshalEnvelope(message)
+	if err != nil {
+		slog.Error("Failed to encode event", "Type", message.Type, "Error", err)
+		return true
+	}
+
+	if message.Seq > 0 {
+		fmt.Fprintf(w, "id: %d\n", message.Seq)
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", services.EventName(message.Type), data)
+	return err == nil
+}
+
+// resumeFrom reads the seq of the last room event a reconnecting client got,
+// from the resume_from query parameter or the Last-Event-ID header sent by
+// EventSource. It is 0 when the client has nothing to catch up on.
+func resumeFrom(r *http.Request) (uint64, error) {
+	value := r.URL.Query().Get("resume_from")
+	if value == "" {
+		value = r.Header.Get("Last-Event-ID")
+	}
+
+	if value == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// openAuctionRoom returns the room of a running auction, opening it on this
+// instance if needed. It writes the error response when there is none.
+func (api *Api) openAuctionRoom(w http.ResponseWriter, r *http.Request, productID uuid.UUID) (*services.AuctionRoom, bool) {
+	room, err := api.AuctionLobby.Open(r.Context(), productID)
+	if err != nil {
+		if errors.Is(err, services.ErrProductNotFound) {
+			utils.EncodeJSON(w, r, http.StatusNotFound, map[string]string{
+				"error": "no product with given id",
+			})
+			return nil, false
+		}
+
+		if errors.Is(err, services.ErrAuctionEnded) {
+			utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+				"error": "the auction has ended",
+			})
+			return nil, false
+		}
+
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected error, try again later.",
+		})
+		return nil, false
+	}
+
+	return room, true
+}
@@ -1,22 +1,245 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"log/slog"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
 
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/services"
+	"github.com/oThinas/bid/internal/store/pg"
 	"github.com/oThinas/bid/internal/utils"
 )
 
+// AuthMiddleware authenticates requests with the session cookie. Requests
+// carrying a bearer token are refused, even alongside a valid session: they
+// skip the CSRF check, see CSRFMiddleware, so they must not be let in on
+// ambient credentials.
 func (api *Api) AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := bearerToken(r); ok {
+			utils.EncodeJSON(w, r, http.StatusUnauthorized, map[string]string{
+				"error": "this endpoint does not accept api tokens",
+			})
+			return
+		}
+
 		slog.Info("AuthMiddleware", "Session", r.Context().Value(AuthenticatedUserID))
-		if !api.Sessions.Exists(r.Context(), AuthenticatedUserID) {
+		userID, ok := api.Sessions.Get(r.Context(), AuthenticatedUserID).(uuid.UUID)
+		if !ok {
 			utils.EncodeJSON(w, r, http.StatusUnauthorized, map[string]string{
 				"error": "must be logged in",
 			})
 			return
 		}
 
+		if err := api.UserService.EnsureActive(r.Context(), userID); err != nil {
+			if errors.Is(err, services.ErrAccountSuspended) || errors.Is(err, services.ErrUserNotFound) {
+				_ = api.Sessions.Destroy(r.Context())
+				utils.EncodeJSON(w, r, http.StatusForbidden, map[string]string{
+					"error": "account is not active",
+				})
+				return
+			}
+
+			utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+				"error": "unexpected internal server error",
+			})
+			return
+		}
+
+		if err := api.SessionService.TouchSession(r.Context(), api.Sessions.Token(r.Context())); err != nil {
+			slog.Error("failed to update session last seen", "Error", err)
+		}
+
 		next.ServeHTTP(w, r)
 	})
 }
+
+// BearerAuthMiddleware authenticates requests carrying an
+// "Authorization: Bearer" API token and falls back to the session cookie
+// otherwise.
+func (api *Api) BearerAuthMiddleware(next http.Handler) http.Handler {
+	sessionAuth := api.AuthMiddleware(next)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, ok := bearerToken(r)
+		if !ok {
+			sessionAuth.ServeHTTP(w, r)
+			return
+		}
+
+		token, err := api.TokenService.Authenticate(r.Context(), raw)
+		if err != nil {
+			if errors.Is(err, services.ErrInvalidToken) {
+				utils.EncodeJSON(w, r, http.StatusUnauthorized, map[string]string{
+					"error": "invalid or revoked token",
+				})
+				return
+			}
+
+			utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+				"error": "unexpected internal server error",
+			})
+			return
+		}
+
+		if err := api.UserService.EnsureActive(r.Context(), token.UserID); err != nil {
+			if errors.Is(err, services.ErrAccountSuspended) || errors.Is(err, services.ErrUserNotFound) {
+				utils.EncodeJSON(w, r, http.StatusForbidden, map[string]string{
+					"error": "account is not active",
+				})
+				return
+			}
+
+			utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+				"error": "unexpected internal server error",
+			})
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), apiTokenContextKey, token)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireScope rejects token authenticated requests whose token was not
+// granted scope. Session authenticated requests are always allowed through.
+func (api *Api) RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token, ok := requestApiToken(r); ok && !services.HasScope(token, scope) {
+				utils.EncodeJSON(w, r, http.StatusForbidden, map[string]string{
+					"error": "token is missing the " + scope + " scope",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireModerator must run after AuthMiddleware.
+func (api *Api) RequireModerator(next http.Handler) http.Handler {
+	return api.requireRole(services.RoleModerator, services.RoleAdmin)(next)
+}
+
+// RequireAdmin must run after AuthMiddleware.
+func (api *Api) RequireAdmin(next http.Handler) http.Handler {
+	return api.requireRole(services.RoleAdmin)(next)
+}
+
+// requireRole rejects requests of users whose role is none of roles.
+func (api *Api) requireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID, ok := api.authenticatedUserID(r)
+			if !ok {
+				utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+					"error": "unexpected internal server error",
+				})
+				return
+			}
+
+			user, err := api.UserService.GetUserByID(r.Context(), userID)
+			if err != nil {
+				utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+					"error": "unexpected internal server error",
+				})
+				return
+			}
+
+			if !slices.Contains(roles, user.Role) {
+				utils.EncodeJSON(w, r, http.StatusForbidden, map[string]string{
+					"error": "insufficient permissions",
+				})
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// purgeUserSessions destroys every stored session that belongs to userID.
+func (api *Api) purgeUserSessions(ctx context.Context, userID uuid.UUID) error {
+	return api.Sessions.Iterate(ctx, func(ctx context.Context) error {
+		id, ok := api.Sessions.Get(ctx, AuthenticatedUserID).(uuid.UUID)
+		if !ok || id != userID {
+			return nil
+		}
+
+		return api.Sessions.Destroy(ctx)
+	})
+}
+
+// revokeOtherSessions logs the user out of every session but the one of
+// currentToken and returns how many were revoked. Sessions opened before
+// their metadata was recorded, see SessionService, are only found in the
+// session store.
+func (api *Api) revokeOtherSessions(ctx context.Context, userID uuid.UUID, currentToken string) (int64, error) {
+	revoked, err := api.SessionService.RevokeOtherSessions(ctx, userID, currentToken)
+	if err != nil {
+		return 0, err
+	}
+
+	purged, err := api.purgeOtherUserSessions(ctx, userID, currentToken)
+	return revoked + purged, err
+}
+
+// purgeOtherUserSessions destroys every stored session that belongs to userID
+// but the one of currentToken, and returns how many were destroyed.
+func (api *Api) purgeOtherUserSessions(ctx context.Context, userID uuid.UUID, currentToken string) (int64, error) {
+	var purged int64
+	err := api.Sessions.Iterate(ctx, func(ctx context.Context) error {
+		id, ok := api.Sessions.Get(ctx, AuthenticatedUserID).(uuid.UUID)
+		if !ok || id != userID || api.Sessions.Token(ctx) == currentToken {
+			return nil
+		}
+
+		purged++
+		return api.Sessions.Destroy(ctx)
+	})
+
+	return purged, err
+}
+
+// authenticatedUserID returns the ID of the user behind the request, whether
+// it was authenticated with an API token or a session.
+func (api *Api) authenticatedUserID(r *http.Request) (uuid.UUID, bool) {
+	if token, ok := requestApiToken(r); ok {
+		return token.UserID, true
+	}
+
+	userID, ok := api.Sessions.Get(r.Context(), AuthenticatedUserID).(uuid.UUID)
+	return userID, ok
+}
+
+func requestApiToken(r *http.Request) (pg.ApiToken, bool) {
+	token, ok := r.Context().Value(apiTokenContextKey).(pg.ApiToken)
+	return token, ok
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+
+	return token, true
+}
+
+// clientIP returns the address of the peer that sent the request.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
@@ -0,0 +1,66 @@
+package api
+
+import (
+	"context"
+	"encoding/gob"
+	"slices"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/google/uuid"
+)
+
+func TestPurgeOtherUserSessions(t *testing.T) {
+	gob.Register(uuid.UUID{})
+
+	api := Api{Sessions: scs.New()}
+	ctx := context.Background()
+
+	login := func(userID uuid.UUID) string {
+		t.Helper()
+
+		ctx, err := api.Sessions.Load(ctx, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		api.Sessions.Put(ctx, AuthenticatedUserID, userID)
+		token, _, err := api.Sessions.Commit(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return token
+	}
+
+	user, other := uuid.New(), uuid.New()
+	current := login(user)
+	login(user)
+	login(user)
+	kept := login(other)
+
+	purged, err := api.purgeOtherUserSessions(ctx, user, current)
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+
+	if purged != 2 {
+		t.Errorf("purged = %d, want 2", purged)
+	}
+
+	var remaining []string
+	err = api.Sessions.Iterate(ctx, func(ctx context.Context) error {
+		remaining = append(remaining, api.Sessions.Token(ctx))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(remaining)
+	want := []string{current, kept}
+	slices.Sort(want)
+	if !slices.Equal(remaining, want) {
+		t.Errorf("remaining sessions = %v, want %v", remaining, want)
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/services"
+	"github.com/oThinas/bid/internal/usecase/bids"
+	"github.com/oThinas/bid/internal/utils"
+)
+
+// handlePlaceBid lets clients that can't keep a websocket open bid over
+// plain HTTP. The bid still goes through the auction room, so websocket and
+// event stream subscribers see it like any other.
+func (api *Api) handlePlaceBid(w http.ResponseWriter, r *http.Request) {
+	productID, err := uuid.Parse(chi.URLParam(r, "productID"))
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid product id",
+		})
+		return
+	}
+
+	data, problems, err := utils.DecodeJSON[bids.PlaceBidRequest](r)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusUnprocessableEntity, problems)
+		return
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	room, ok := api.openAuctionRoom(w, r, productID)
+	if !ok {
+		return
+	}
+
+	bid, err := room.PlaceBid(r.Context(), userID, data.Amount)
+	if err != nil {
+		if errors.Is(err, services.ErrBidAmountTooLow) {
+			utils.EncodeJSON(w, r, http.StatusUnprocessableEntity, map[string]string{
+				"error": "bid amount is too low",
+			})
+			return
+		}
+
+		if errors.Is(err, services.ErrAuctionEnded) {
+			utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+				"error": "the auction has ended",
+			})
+			return
+		}
+
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusCreated, map[string]any{
+		"data": bid,
+	})
+}
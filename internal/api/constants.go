@@ -2,4 +2,24 @@ package api
 
 const (
 	AuthenticatedUserID = "authenticatedUserID"
+	CSRFCookieName      = "csrf_token"
+	CSRFHeaderName      = "X-CSRF-Token"
+)
+
+const (
+	// PaymentWebhookPath receives the webhooks of the payment provider.
+	PaymentWebhookPath = "/api/v1/payments/webhook"
+	// FakePaymentChallengePath serves the 3-D Secure challenges of the fake
+	// payment provider.
+	FakePaymentChallengePath = "/api/v1/payments/fake/challenge"
+)
+
+type contextKey string
+
+const (
+	// apiTokenContextKey holds the pg.ApiToken of requests authenticated with
+	// a bearer token.
+	apiTokenContextKey contextKey = "apiToken"
+	// csrfTokenContextKey holds the CSRF token issued to or sent by the client.
+	csrfTokenContextKey contextKey = "csrfToken"
 )
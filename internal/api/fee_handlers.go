@@ -0,0 +1,116 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/services"
+	usecase "github.com/oThinas/bid/internal/usecase/fees"
+	"github.com/oThinas/bid/internal/utils"
+)
+
+func (api *Api) handleListFeeRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := api.FeeService.ListRules(r.Context())
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": rules,
+	})
+}
+
+func (api *Api) handleCreateFeeRule(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := utils.DecodeJSON[usecase.FeeRuleRequest](r)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusUnprocessableEntity, problems)
+		return
+	}
+
+	rule, err := api.FeeService.CreateRule(r.Context(), feeRuleParams(data))
+	if err != nil {
+		api.feeRuleError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusCreated, map[string]any{
+		"data": rule,
+	})
+}
+
+func (api *Api) handleUpdateFeeRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "ruleID"))
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid fee rule id",
+		})
+		return
+	}
+
+	data, problems, err := utils.DecodeJSON[usecase.FeeRuleRequest](r)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusUnprocessableEntity, problems)
+		return
+	}
+
+	rule, err := api.FeeService.UpdateRule(r.Context(), ruleID, feeRuleParams(data))
+	if err != nil {
+		api.feeRuleError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": rule,
+	})
+}
+
+func (api *Api) handleDeleteFeeRule(w http.ResponseWriter, r *http.Request) {
+	ruleID, err := uuid.Parse(chi.URLParam(r, "ruleID"))
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid fee rule id",
+		})
+		return
+	}
+
+	if err := api.FeeService.DeleteRule(r.Context(), ruleID); err != nil {
+		api.feeRuleError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]string{
+		"data": "fee rule deleted",
+	})
+}
+
+func feeRuleParams(data usecase.FeeRuleRequest) services.FeeRuleParams {
+	return services.FeeRuleParams{
+		Category: data.Category,
+		MinPrice: data.MinPrice,
+		MaxPrice: data.MaxPrice,
+		Kind:     data.Kind,
+		Rate:     data.Rate,
+		Amount:   data.Amount,
+		Cap:      data.Cap,
+		Tiers:    data.Tiers,
+		Priority: data.Priority,
+	}
+}
+
+func (api *Api) feeRuleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrFeeRuleNotFound):
+		utils.EncodeJSON(w, r, http.StatusNotFound, map[string]string{
+			"error": "no fee rule with given id",
+		})
+	default:
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+	}
+}
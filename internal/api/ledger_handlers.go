@@ -0,0 +1,111 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/services"
+	usecase "github.com/oThinas/bid/internal/usecase/ledger"
+	"github.com/oThinas/bid/internal/utils"
+)
+
+func (api *Api) handleGetBalance(w http.ResponseWriter, r *http.Request) {
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	api.writeBalance(w, r, userID)
+}
+
+func (api *Api) handleGetUserBalance(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid user id",
+		})
+		return
+	}
+
+	api.writeBalance(w, r, userID)
+}
+
+func (api *Api) writeBalance(w http.ResponseWriter, r *http.Request, userID uuid.UUID) {
+	balance, err := api.LedgerService.Balance(r.Context(), userID)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": balance,
+	})
+}
+
+func (api *Api) handleListLedgerEntries(w http.ResponseWriter, r *http.Request) {
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	entries, err := api.LedgerService.ListEntries(r.Context(), userID)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": entries,
+	})
+}
+
+func (api *Api) handleRequestPayout(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := utils.DecodeJSON[usecase.RequestPayoutRequest](r)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusUnprocessableEntity, problems)
+		return
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	payout, err := api.LedgerService.RequestPayout(r.Context(), userID, data.IdempotencyKey, data.Amount)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidPayoutAmount):
+			utils.EncodeJSON(w, r, http.StatusUnprocessableEntity, map[string]string{
+				"amount": err.Error(),
+			})
+		case errors.Is(err, services.ErrInsufficientBalance), errors.Is(err, services.ErrPayoutKeyReused):
+			utils.EncodeJSON(w, r, http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+		default:
+			utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+				"error": "unexpected internal server error",
+			})
+		}
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusCreated, map[string]any{
+		"data": payout,
+	})
+}
@@ -0,0 +1,16 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/oThinas/bid/internal/services"
+	"github.com/oThinas/bid/internal/utils"
+)
+
+func (api *Api) handleGetMetrics(w http.ResponseWriter, r *http.Request) {
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": map[string]any{
+			"fan_out": services.FanOutMetrics(),
+		},
+	})
+}
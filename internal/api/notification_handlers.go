@@ -0,0 +1,264 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/services"
+	"github.com/oThinas/bid/internal/usecase/notifications"
+	"github.com/oThinas/bid/internal/utils"
+)
+
+// handleListNotifications returns a page of the inbox, newest first. The
+// next page starts after the next_cursor of the response, which is only set
+// when there may be more.
+func (api *Api) handleListNotifications(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := services.NotificationPageSize
+	if raw := query.Get("limit"); raw != "" {
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 1 || value > services.MaxNotificationPageSize {
+			utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+				"error": "limit must be between 1 and " + strconv.Itoa(services.MaxNotificationPageSize),
+			})
+			return
+		}
+
+		limit = value
+	}
+
+	var cursor uuid.UUID
+	if raw := query.Get("cursor"); raw != "" {
+		value, err := uuid.Parse(raw)
+		if err != nil {
+			utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+				"error": "invalid cursor",
+			})
+			return
+		}
+
+		cursor = value
+	}
+
+	unreadOnly, err := strconv.ParseBool(query.Get("unread"))
+	if query.Get("unread") != "" && err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid unread",
+		})
+		return
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	page, err := api.NotificationService.ListNotifications(r.Context(), userID, cursor, unreadOnly, int32(limit))
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	unread, err := api.NotificationService.CountUnread(r.Context(), userID)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	var nextCursor *uuid.UUID
+	if len(page) == limit {
+		nextCursor = &page[len(page)-1].ID
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data":         page,
+		"next_cursor":  nextCursor,
+		"unread_count": unread,
+	})
+}
+
+func (api *Api) handleMarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	notificationID, err := uuid.Parse(chi.URLParam(r, "notificationID"))
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid notification id",
+		})
+		return
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if err := api.NotificationService.MarkRead(r.Context(), userID, notificationID); err != nil {
+		if errors.Is(err, services.ErrNotificationNotFound) {
+			utils.EncodeJSON(w, r, http.StatusNotFound, map[string]string{
+				"error": "no notification with given id",
+			})
+			return
+		}
+
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]string{
+		"data": "notification marked as read",
+	})
+}
+
+func (api *Api) handleMarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	marked, err := api.NotificationService.MarkAllRead(r.Context(), userID)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": map[string]int64{"marked": marked},
+	})
+}
+
+// handleSubscribeUserToNotifications opens a websocket on which the new
+// notifications of the user are pushed as they are created.
+func (api *Api) handleSubscribeUserToNotifications(w http.ResponseWriter, r *http.Request) {
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected error, try again later.",
+		})
+		return
+	}
+
+	conn, err := api.WsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "could not upgrade connection to a websocket protocol",
+		})
+		return
+	}
+
+	client := services.NewNotificationClient(conn, api.NotificationHub, userID)
+	api.NotificationHub.Register(client)
+
+	go client.ReadEventLoop()
+	go client.WriteEventLoop()
+}
+
+func (api *Api) handleGetNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	preferences, err := api.NotificationService.Preferences(r.Context(), userID)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": preferences,
+	})
+}
+
+func (api *Api) handleUpdateNotificationPreferences(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := utils.DecodeJSON[notifications.UpdatePreferencesRequest](r)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusUnprocessableEntity, problems)
+		return
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if err := api.NotificationService.UpdatePreferences(r.Context(), userID, data.Preferences); err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	preferences, err := api.NotificationService.Preferences(r.Context(), userID)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": preferences,
+	})
+}
+
+func (api *Api) handleUpdateLocale(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := utils.DecodeJSON[notifications.UpdateLocaleRequest](r)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusUnprocessableEntity, problems)
+		return
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if err := api.NotificationService.SetLocale(r.Context(), userID, data.Locale); err != nil {
+		if errors.Is(err, services.ErrUnsupportedLocale) {
+			utils.EncodeJSON(w, r, http.StatusUnprocessableEntity, map[string]string{
+				"locale": err.Error(),
+			})
+			return
+		}
+
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]string{
+		"data": "locale updated",
+	})
+}
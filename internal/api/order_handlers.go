@@ -0,0 +1,121 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/services"
+	"github.com/oThinas/bid/internal/usecase/orders"
+	"github.com/oThinas/bid/internal/utils"
+)
+
+func (api *Api) handleListOrders(w http.ResponseWriter, r *http.Request) {
+	role := r.URL.Query().Get("role")
+	if role != "" && role != services.OrderActorBuyer && role != services.OrderActorSeller {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "role must be any of: buyer, seller",
+		})
+		return
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	orders, err := api.OrderService.ListOrders(r.Context(), userID, role)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": orders,
+	})
+}
+
+func (api *Api) handleGetOrder(w http.ResponseWriter, r *http.Request) {
+	orderID, userID, ok := api.orderRequest(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := api.OrderService.GetOrder(r.Context(), userID, orderID)
+	if err != nil {
+		api.orderError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": order,
+	})
+}
+
+func (api *Api) handleUpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := utils.DecodeJSON[orders.UpdateOrderStatusRequest](r)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusUnprocessableEntity, problems)
+		return
+	}
+
+	orderID, userID, ok := api.orderRequest(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := api.OrderService.UpdateStatus(r.Context(), userID, orderID, data.Status, data.TrackingNumber)
+	if err != nil {
+		api.orderError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": order,
+	})
+}
+
+// orderRequest reads the order ID of the URL and the authenticated user,
+// writing the error response when either is missing.
+func (api *Api) orderRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	orderID, err := uuid.Parse(chi.URLParam(r, "orderID"))
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid order id",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return orderID, userID, true
+}
+
+func (api *Api) orderError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrOrderNotFound):
+		utils.EncodeJSON(w, r, http.StatusNotFound, map[string]string{
+			"error": "no order with given id",
+		})
+	case errors.Is(err, services.ErrInvalidOrderTransition):
+		utils.EncodeJSON(w, r, http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oThinas/bid/internal/payments"
+	"github.com/oThinas/bid/internal/services"
+	usecase "github.com/oThinas/bid/internal/usecase/payments"
+	"github.com/oThinas/bid/internal/utils"
+)
+
+// maxPaymentWebhookSize bounds the body of payment webhooks.
+const maxPaymentWebhookSize = 1 << 20
+
+func (api *Api) handlePayOrder(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := utils.DecodeJSON[usecase.PayOrderRequest](r)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusUnprocessableEntity, problems)
+		return
+	}
+
+	orderID, userID, ok := api.orderRequest(w, r)
+	if !ok {
+		return
+	}
+
+	payment, err := api.PaymentService.Pay(r.Context(), userID, orderID, data.PaymentMethod)
+	if err != nil {
+		api.paymentError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusCreated, map[string]any{
+		"data": payment,
+	})
+}
+
+func (api *Api) handleListOrderPayments(w http.ResponseWriter, r *http.Request) {
+	orderID, userID, ok := api.orderRequest(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := api.PaymentService.ListPayments(r.Context(), userID, orderID)
+	if err != nil {
+		api.paymentError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": list,
+	})
+}
+
+func (api *Api) handleRefundOrder(w http.ResponseWriter, r *http.Request) {
+	orderID, userID, ok := api.orderRequest(w, r)
+	if !ok {
+		return
+	}
+
+	payment, err := api.PaymentService.Refund(r.Context(), userID, orderID)
+	if err != nil {
+		api.paymentError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": payment,
+	})
+}
+
+// handlePaymentWebhook receives the events of the payment provider. Anything
+// but a 2xx response makes the provider send the event again.
+func (api *Api) handlePaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxPaymentWebhookSize))
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid body",
+		})
+		return
+	}
+
+	if err := api.PaymentService.HandleWebhook(r.Context(), r.Header, body); err != nil {
+		if errors.Is(err, payments.ErrInvalidSignature) {
+			utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+				"error": "invalid signature",
+			})
+			return
+		}
+
+		api.paymentError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]string{
+		"data": "event received",
+	})
+}
+
+// handleFakePaymentChallenge serves the 3-D Secure challenges of the fake
+// payment provider: a page to pass or fail the challenge, which is completed
+// once a result is picked.
+func (api *Api) handleFakePaymentChallenge(w http.ResponseWriter, r *http.Request) {
+	intentID := chi.URLParam(r, "intentID")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	result := r.URL.Query().Get("result")
+	if result != "pass" && result != "fail" {
+		fmt.Fprintf(w, `<!DOCTYPE html>
+<html lang="en">
+  <body>
+    <p>Fake 3-D Secure challenge for payment intent %[1]s.</p>
+    <p><a href="?result=pass">Pass</a> or <a href="?result=fail">Fail</a></p>
+  </body>
+</html>
+`, html.EscapeString(intentID))
+		return
+	}
+
+	intent, err := api.FakePayments.CompleteChallenge(intentID, result == "pass")
+	if err != nil {
+		if errors.Is(err, payments.ErrIntentNotFound) || errors.Is(err, payments.ErrInvalidIntentState) {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, "<p>This challenge doesn't exist or is already completed.</p>")
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintln(w, "<p>Unexpected internal server error.</p>")
+		return
+	}
+
+	fmt.Fprintf(w, "<p>Challenge completed, the payment intent is now %s.</p>\n", html.EscapeString(intent.Status))
+}
+
+func (api *Api) paymentError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrOrderNotFound):
+		utils.EncodeJSON(w, r, http.StatusNotFound, map[string]string{
+			"error": "no order with given id",
+		})
+	case errors.Is(err, services.ErrPaymentNotFound):
+		utils.EncodeJSON(w, r, http.StatusNotFound, map[string]string{
+			"error": "no payment found",
+		})
+	case errors.Is(err, services.ErrOrderNotPayable),
+		errors.Is(err, services.ErrPaymentInProgress),
+		errors.Is(err, services.ErrInvalidOrderTransition):
+		utils.EncodeJSON(w, r, http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+	}
+}
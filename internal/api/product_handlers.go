@@ -1,10 +1,9 @@
 package api
 
 import (
-	"context"
+	"log/slog"
 	"net/http"
 
-	"github.com/google/uuid"
 	"github.com/oThinas/bid/internal/services"
 	"github.com/oThinas/bid/internal/usecase/products"
 	"github.com/oThinas/bid/internal/utils"
@@ -17,7 +16,7 @@ func (api *Api) handleCreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userID, ok := api.Sessions.Get(r.Context(), AuthenticatedUserID).(uuid.UUID)
+	userID, ok := api.authenticatedUserID(r)
 	if !ok {
 		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
 			"error": "unexpected internal server error",
@@ -32,6 +31,7 @@ func (api *Api) handleCreateProduct(w http.ResponseWriter, r *http.Request) {
 		data.Description,
 		data.BasePrice,
 		data.AuctionEnd,
+		data.Category,
 	)
 	if err != nil {
 		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
@@ -40,16 +40,24 @@ func (api *Api) handleCreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx, _ := context.WithDeadline(context.Background(), data.AuctionEnd)
-	auctionRoom := services.NewAuctionRoom(ctx, productID, api.BidsService)
+	// The product sells at least at its base price, so that is what the
+	// estimate is made for; the fee is settled with the rules in force when
+	// the auction ends.
+	var estimate *services.FeeQuote
+	quote, err := api.FeeService.Estimate(r.Context(), data.Category, data.BasePrice)
+	if err != nil {
+		slog.Error("Failed to estimate fee", "ProductID", productID, "Error", err)
+	} else {
+		estimate = &quote
+	}
 
-	go auctionRoom.Run()
-	api.AuctionLobby.Lock()
-	api.AuctionLobby.Rooms[productID] = auctionRoom
-	api.AuctionLobby.Unlock()
+	if _, err := api.AuctionLobby.Open(r.Context(), productID); err != nil {
+		slog.Error("Failed to open auction room", "ProductID", productID, "Error", err)
+	}
 
 	utils.EncodeJSON(w, r, http.StatusCreated, map[string]any{
-		"data":    productID,
-		"message": "auction room created",
+		"data":         productID,
+		"message":      "auction room created",
+		"fee_estimate": estimate,
 	})
 }
@@ -3,13 +3,24 @@ package api
 import (
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/oThinas/bid/internal/services"
 )
 
 func (api *Api) BindRoutes() {
-	api.Router.Use(middleware.RequestID, middleware.Recoverer, middleware.Logger, api.Sessions.LoadAndSave)
+	api.Router.Use(
+		middleware.RequestID,
+		middleware.Recoverer,
+		middleware.Logger,
+		api.CORSMiddleware,
+		api.Sessions.LoadAndSave,
+		api.CSRFMiddleware,
+	)
 
 	api.Router.Route("/api", func(r chi.Router) {
 		r.Route("/v1", func(r chi.Router) {
+			r.Get("/csrf", api.handleGetCSRFToken)
+			r.With(api.AuthMiddleware, api.RequireModerator).Get("/metrics", api.handleGetMetrics)
+
 			r.Route("/users", func(r chi.Router) {
 				r.Post("/signup", api.handleSignupUser)
 				r.Post("/login", api.handleLoginUser)
@@ -18,15 +29,100 @@ func (api *Api) BindRoutes() {
 					r.Use(api.AuthMiddleware)
 
 					r.Post("/logout", api.handleLogoutUser)
+
+					r.Put("/me/password", api.handleChangePassword)
+
+					r.Route("/me/sessions", func(r chi.Router) {
+						r.Get("/", api.handleListSessions)
+						r.Delete("/", api.handleRevokeOtherSessions)
+						r.Delete("/{sessionID}", api.handleRevokeSession)
+					})
+
+					r.Route("/me/tokens", func(r chi.Router) {
+						r.Get("/", api.handleListTokens)
+						r.Post("/", api.handleCreateToken)
+						r.Delete("/{tokenID}", api.handleRevokeToken)
+					})
+
+					r.Route("/me/notifications", func(r chi.Router) {
+						r.Get("/", api.handleListNotifications)
+						r.Get("/subscribe", api.handleSubscribeUserToNotifications)
+						r.Post("/read-all", api.handleMarkAllNotificationsRead)
+						r.Post("/{notificationID}/read", api.handleMarkNotificationRead)
+					})
+
+					r.Get("/me/notification-preferences", api.handleGetNotificationPreferences)
+					r.Put("/me/notification-preferences", api.handleUpdateNotificationPreferences)
+					r.Put("/me/locale", api.handleUpdateLocale)
+
+					r.Get("/me/balance", api.handleGetBalance)
+					r.Get("/me/ledger", api.handleListLedgerEntries)
+					r.Post("/me/payouts", api.handleRequestPayout)
+
+					r.Route("/me/webhooks", func(r chi.Router) {
+						r.Get("/", api.handleListWebhooks)
+						r.Post("/", api.handleCreateWebhook)
+						r.Delete("/{webhookID}", api.handleDeleteWebhook)
+						r.Get("/{webhookID}/deliveries", api.handleListWebhookDeliveries)
+						r.Post("/{webhookID}/test", api.handleSendTestWebhook)
+					})
+
+					r.Group(func(r chi.Router) {
+						r.Use(api.RequireModerator)
+
+						r.Post("/{userID}/suspend", api.handleSuspendUser)
+						r.Post("/{userID}/reinstate", api.handleReinstateUser)
+						r.Get("/{userID}/balance", api.handleGetUserBalance)
+					})
 				})
 			})
 
+			r.Route("/orders", func(r chi.Router) {
+				r.Use(api.AuthMiddleware)
+
+				r.Get("/", api.handleListOrders)
+				r.Get("/{orderID}", api.handleGetOrder)
+				r.Post("/{orderID}/status", api.handleUpdateOrderStatus)
+				r.Get("/{orderID}/payments", api.handleListOrderPayments)
+				r.Post("/{orderID}/payments", api.handlePayOrder)
+				r.Post("/{orderID}/refund", api.handleRefundOrder)
+				r.Post("/{orderID}/second-chance", api.handleMakeSecondChanceOffer)
+			})
+
+			r.Route("/second-chance-offers", func(r chi.Router) {
+				r.Use(api.AuthMiddleware)
+
+				r.Get("/", api.handleListSecondChanceOffers)
+				r.Post("/{offerID}/accept", api.handleAcceptSecondChanceOffer)
+				r.Post("/{offerID}/decline", api.handleDeclineSecondChanceOffer)
+			})
+
+			r.Route("/fee-rules", func(r chi.Router) {
+				r.Use(api.AuthMiddleware, api.RequireAdmin)
+
+				r.Get("/", api.handleListFeeRules)
+				r.Post("/", api.handleCreateFeeRule)
+				r.Put("/{ruleID}", api.handleUpdateFeeRule)
+				r.Delete("/{ruleID}", api.handleDeleteFeeRule)
+			})
+
+			r.Route("/payments", func(r chi.Router) {
+				r.Post("/webhook", api.handlePaymentWebhook)
+
+				if api.FakePayments != nil {
+					r.Get("/fake/challenge/{intentID}", api.handleFakePaymentChallenge)
+				}
+			})
+
 			r.Route("/products", func(r chi.Router) {
 				r.Group(func(r chi.Router) {
-					r.Use(api.AuthMiddleware)
+					r.Use(api.BearerAuthMiddleware)
 
-					r.Post("/", api.handleCreateProduct)
-					r.Get("/subscribe/{productID}", api.handleSubscribeUserToAuction)
+					r.With(api.RequireScope(services.ScopeProductsWrite)).Post("/", api.handleCreateProduct)
+					r.With(api.RequireScope(services.ScopeAuctionsRead)).Get("/subscribe", api.handleSubscribeUserToAuctions)
+					r.With(api.RequireScope(services.ScopeAuctionsRead)).Get("/subscribe/{productID}", api.handleSubscribeUserToAuction)
+					r.With(api.RequireScope(services.ScopeAuctionsRead)).Get("/{productID}/events", api.handleStreamAuctionEvents)
+					r.With(api.RequireScope(services.ScopeBidsWrite)).Post("/{productID}/bids", api.handlePlaceBid)
 				})
 			})
 		})
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/services"
+	"github.com/oThinas/bid/internal/utils"
+)
+
+func (api *Api) handleMakeSecondChanceOffer(w http.ResponseWriter, r *http.Request) {
+	orderID, userID, ok := api.orderRequest(w, r)
+	if !ok {
+		return
+	}
+
+	offer, err := api.SecondChanceService.MakeOffer(r.Context(), userID, orderID)
+	if err != nil {
+		api.secondChanceError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusCreated, map[string]any{
+		"data": offer,
+	})
+}
+
+func (api *Api) handleListSecondChanceOffers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	offers, err := api.SecondChanceService.ListOffers(r.Context(), userID)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": offers,
+	})
+}
+
+func (api *Api) handleAcceptSecondChanceOffer(w http.ResponseWriter, r *http.Request) {
+	offerID, userID, ok := api.secondChanceRequest(w, r)
+	if !ok {
+		return
+	}
+
+	order, err := api.SecondChanceService.AcceptOffer(r.Context(), userID, offerID)
+	if err != nil {
+		api.secondChanceError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusCreated, map[string]any{
+		"data": order,
+	})
+}
+
+func (api *Api) handleDeclineSecondChanceOffer(w http.ResponseWriter, r *http.Request) {
+	offerID, userID, ok := api.secondChanceRequest(w, r)
+	if !ok {
+		return
+	}
+
+	offer, err := api.SecondChanceService.DeclineOffer(r.Context(), userID, offerID)
+	if err != nil {
+		api.secondChanceError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": offer,
+	})
+}
+
+// secondChanceRequest reads the offer ID of the URL and the authenticated
+// user, writing the error response when either is missing.
+func (api *Api) secondChanceRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	offerID, err := uuid.Parse(chi.URLParam(r, "offerID"))
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid offer id",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return offerID, userID, true
+}
+
+func (api *Api) secondChanceError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, services.ErrOrderNotFound):
+		utils.EncodeJSON(w, r, http.StatusNotFound, map[string]string{
+			"error": "no order with given id",
+		})
+	case errors.Is(err, services.ErrSecondChanceNotFound):
+		utils.EncodeJSON(w, r, http.StatusNotFound, map[string]string{
+			"error": "no offer with given id",
+		})
+	case errors.Is(err, services.ErrSecondChanceUnavailable),
+		errors.Is(err, services.ErrNoEligibleBidder),
+		errors.Is(err, services.ErrSecondChanceClosed):
+		utils.EncodeJSON(w, r, http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+	default:
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+	}
+}
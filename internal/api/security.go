@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/oThinas/bid/internal/utils"
+)
+
+// CORSMiddleware allows browsers on AllowedOrigins to call the API with
+// credentials and answers their preflight requests.
+func (api *Api) CORSMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		allowed := api.isAllowedOrigin(origin)
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			if !allowed {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE")
+			w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, "+CSRFHeaderName)
+			w.Header().Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CSRFMiddleware implements the double-submit cookie pattern: every client
+// gets a random token in a cookie readable by JavaScript, and state changing
+// requests must echo it back in the X-CSRF-Token header. Payment webhooks,
+// which are signed by the provider, are exempt. So are requests carrying a
+// bearer token: session authentication refuses them, see AuthMiddleware, so
+// they only get in with the token, which is no ambient credential.
+func (api *Api) CSRFMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := api.ensureCSRFCookie(w, r)
+		r = r.WithContext(context.WithValue(r.Context(), csrfTokenContextKey, token))
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if _, ok := bearerToken(r); ok || r.URL.Path == PaymentWebhookPath {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := r.Header.Get(CSRFHeaderName)
+		if header == "" || subtle.ConstantTimeCompare([]byte(header), []byte(token)) != 1 {
+			utils.EncodeJSON(w, r, http.StatusForbidden, map[string]string{
+				"error": "missing or invalid csrf token",
+			})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// CheckOrigin is used by the websocket upgrader. Requests without an Origin
+// header don't come from browsers and are allowed, as are same-origin
+// requests and AllowedOrigins.
+func (api *Api) CheckOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	return api.isAllowedOrigin(origin)
+}
+
+func (api *Api) handleGetCSRFToken(w http.ResponseWriter, r *http.Request) {
+	token, _ := r.Context().Value(csrfTokenContextKey).(string)
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]string{
+		"data": token,
+	})
+}
+
+func (api *Api) isAllowedOrigin(origin string) bool {
+	return slices.Contains(api.AllowedOrigins, origin)
+}
+
+// ensureCSRFCookie returns the CSRF token of the request, issuing a new cookie
+// when the client doesn't have one yet.
+func (api *Api) ensureCSRFCookie(w http.ResponseWriter, r *http.Request) string {
+	if cookie, err := r.Cookie(CSRFCookieName); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	http.SetCookie(w, &http.Cookie{
+		Name:     CSRFCookieName,
+		Value:    token,
+		Path:     "/",
+		Secure:   api.Sessions.Cookie.Secure,
+		SameSite: api.Sessions.Cookie.SameSite,
+	})
+
+	return token
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/gob"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alexedwards/scs/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+func TestCSRFExemptionNeedsBearerAuthentication(t *testing.T) {
+	gob.Register(uuid.UUID{})
+
+	api := Api{Sessions: scs.New()}
+	reached := false
+
+	router := chi.NewMux()
+	router.Use(api.Sessions.LoadAndSave, api.CSRFMiddleware)
+	router.Get("/login", func(w http.ResponseWriter, r *http.Request) {
+		api.Sessions.Put(r.Context(), AuthenticatedUserID, uuid.New())
+	})
+	router.With(api.AuthMiddleware).Post("/protected", func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	})
+
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &http.Client{Jar: jar}
+
+	// Log in, which also issues the CSRF cookie.
+	res, err := client.Get(server.URL + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+	}{
+		{name: "session without csrf token", status: http.StatusForbidden},
+		{name: "session with an unknown bearer token", authorization: "Bearer bogus", status: http.StatusUnauthorized},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reached = false
+
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/protected", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+
+			if res.StatusCode != test.status {
+				t.Errorf("status = %d, want %d", res.StatusCode, test.status)
+			}
+
+			if reached {
+				t.Error("the request reached the handler on the session cookie alone")
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/services"
+	"github.com/oThinas/bid/internal/usecase/tokens"
+	"github.com/oThinas/bid/internal/utils"
+)
+
+func (api *Api) handleCreateToken(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := utils.DecodeJSON[tokens.CreateTokenRequest](r)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusUnprocessableEntity, problems)
+		return
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	plain, token, err := api.TokenService.CreateToken(r.Context(), userID, data.Name, data.Scopes)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusCreated, map[string]any{
+		"data": map[string]any{
+			"id":         token.ID,
+			"name":       token.Name,
+			"scopes":     token.Scopes,
+			"token":      plain,
+			"created_at": token.CreatedAt,
+		},
+		"message": "store this token now, it will not be shown again",
+	})
+}
+
+func (api *Api) handleListTokens(w http.ResponseWriter, r *http.Request) {
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	tokens, err := api.TokenService.ListTokens(r.Context(), userID)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": tokens,
+	})
+}
+
+func (api *Api) handleRevokeToken(w http.ResponseWriter, r *http.Request) {
+	tokenID, err := uuid.Parse(chi.URLParam(r, "tokenID"))
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid token id",
+		})
+		return
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if err := api.TokenService.RevokeToken(r.Context(), userID, tokenID); err != nil {
+		if errors.Is(err, services.ErrTokenNotFound) {
+			utils.EncodeJSON(w, r, http.StatusNotFound, map[string]string{
+				"error": "no token with given id",
+			})
+			return
+		}
+
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]string{
+		"data": "token revoked",
+	})
+}
@@ -2,8 +2,12 @@ package api
 
 import (
 	"errors"
+	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/oThinas/bid/internal/services"
 	"github.com/oThinas/bid/internal/usecase/users"
@@ -39,21 +43,53 @@ func (api *Api) handleLoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	ip := clientIP(r)
+	retryAfter, err := api.LoginThrottle.Check(r.Context(), data.Email, ip)
+	if err != nil {
+		if errors.Is(err, services.ErrTooManyLoginAttempts) {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			utils.EncodeJSON(w, r, http.StatusTooManyRequests, map[string]string{
+				"error": "too many failed login attempts, try again later",
+			})
+			return
+		}
+
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
 	id, err := api.UserService.AuthenticateUser(r.Context(), data.Email, data.Password)
 	if err != nil {
 		if errors.Is(err, services.ErrInvalidCredentials) {
+			if err := api.LoginThrottle.RecordFailure(r.Context(), data.Email, ip); err != nil {
+				slog.Error("failed to record login failure", "Error", err)
+			}
+
 			utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
 				"error": "invalid email or password",
 			})
 			return
 		}
 
+		if errors.Is(err, services.ErrAccountSuspended) {
+			utils.EncodeJSON(w, r, http.StatusForbidden, map[string]string{
+				"error": "account suspended",
+			})
+			return
+		}
+
 		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
 			"error": "unexpected internal server error",
 		})
 		return
 	}
 
+	if err := api.LoginThrottle.RecordSuccess(r.Context(), data.Email); err != nil {
+		slog.Error("failed to reset login throttle", "Error", err)
+	}
+
 	err = api.Sessions.RenewToken(r.Context())
 	if err != nil {
 		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
@@ -64,12 +100,24 @@ func (api *Api) handleLoginUser(w http.ResponseWriter, r *http.Request) {
 
 	api.Sessions.Put(r.Context(), AuthenticatedUserID, id)
 
+	err = api.SessionService.RecordSession(r.Context(), api.Sessions.Token(r.Context()), id, clientIP(r), r.UserAgent())
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
 	utils.EncodeJSON(w, r, http.StatusOK, map[string]string{
 		"data": "logged in successfully",
 	})
 }
 
 func (api *Api) handleLogoutUser(w http.ResponseWriter, r *http.Request) {
+	if err := api.SessionService.ForgetSession(r.Context(), api.Sessions.Token(r.Context())); err != nil {
+		slog.Error("failed to delete session metadata", "Error", err)
+	}
+
 	err := api.Sessions.RenewToken(r.Context())
 	if err != nil {
 		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
@@ -83,3 +131,135 @@ func (api *Api) handleLogoutUser(w http.ResponseWriter, r *http.Request) {
 		"data": "logged out successfully",
 	})
 }
+
+func (api *Api) handleChangePassword(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := utils.DecodeJSON[users.ChangePasswordRequest](r)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusUnprocessableEntity, problems)
+		return
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	err = api.UserService.ChangePassword(r.Context(), userID, data.CurrentPassword, data.NewPassword)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+				"error": "current password is incorrect",
+			})
+			return
+		}
+
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	// Changing the password logs the user out everywhere else and renews the
+	// token of the session that made the change.
+	oldToken := api.Sessions.Token(r.Context())
+	if _, err := api.revokeOtherSessions(r.Context(), userID, oldToken); err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if err := api.Sessions.RenewToken(r.Context()); err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if err := api.SessionService.RotateToken(r.Context(), oldToken, api.Sessions.Token(r.Context())); err != nil {
+		slog.Error("failed to rotate session metadata", "Error", err)
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]string{
+		"data": "password changed, other sessions were logged out",
+	})
+}
+
+func (api *Api) handleSuspendUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid user id",
+		})
+		return
+	}
+
+	moderatorID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	if err := api.UserService.SuspendUser(r.Context(), moderatorID, userID); err != nil {
+		switch {
+		case errors.Is(err, services.ErrUserNotFound):
+			utils.EncodeJSON(w, r, http.StatusNotFound, map[string]string{
+				"error": "no user with given id",
+			})
+		case errors.Is(err, services.ErrCannotSuspendSelf), errors.Is(err, services.ErrRoleTooHigh):
+			utils.EncodeJSON(w, r, http.StatusForbidden, map[string]string{
+				"error": err.Error(),
+			})
+		default:
+			utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+				"error": "unexpected internal server error",
+			})
+		}
+		return
+	}
+
+	// AuthMiddleware rejects suspended users on their next request anyway, so a
+	// failed purge only delays the logout instead of leaving the session usable.
+	if err := api.purgeUserSessions(r.Context(), userID); err != nil {
+		slog.Error("failed to purge sessions", "UserID", userID, "Error", err)
+	}
+
+	api.AuctionLobby.DisconnectUser(userID, "account suspended")
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]string{
+		"data": "user suspended",
+	})
+}
+
+func (api *Api) handleReinstateUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := uuid.Parse(chi.URLParam(r, "userID"))
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid user id",
+		})
+		return
+	}
+
+	if err := api.UserService.ReinstateUser(r.Context(), userID); err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			utils.EncodeJSON(w, r, http.StatusNotFound, map[string]string{
+				"error": "no user with given id",
+			})
+			return
+		}
+
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]string{
+		"data": "user reinstated",
+	})
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/services"
+	"github.com/oThinas/bid/internal/usecase/webhooks"
+	"github.com/oThinas/bid/internal/utils"
+)
+
+func (api *Api) handleCreateWebhook(w http.ResponseWriter, r *http.Request) {
+	data, problems, err := utils.DecodeJSON[webhooks.CreateWebhookRequest](r)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusUnprocessableEntity, problems)
+		return
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	webhook, err := api.WebhookService.CreateWebhook(r.Context(), userID, data.URL, data.EventTypes)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusCreated, map[string]any{
+		"data": map[string]any{
+			"id":          webhook.ID,
+			"url":         webhook.Url,
+			"event_types": webhook.EventTypes,
+			"secret":      webhook.Secret,
+			"created_at":  webhook.CreatedAt,
+		},
+		"message": "store this secret now, it will not be shown again",
+	})
+}
+
+func (api *Api) handleListWebhooks(w http.ResponseWriter, r *http.Request) {
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	webhooks, err := api.WebhookService.ListWebhooks(r.Context(), userID)
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": webhooks,
+	})
+}
+
+func (api *Api) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, userID, ok := api.webhookRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := api.WebhookService.DeleteWebhook(r.Context(), userID, webhookID); err != nil {
+		api.webhookError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]string{
+		"data": "webhook deleted",
+	})
+}
+
+func (api *Api) handleListWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	webhookID, userID, ok := api.webhookRequest(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := api.WebhookService.ListDeliveries(r.Context(), userID, webhookID)
+	if err != nil {
+		api.webhookError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusOK, map[string]any{
+		"data": deliveries,
+	})
+}
+
+func (api *Api) handleSendTestWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID, userID, ok := api.webhookRequest(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := api.WebhookService.SendTestEvent(r.Context(), userID, webhookID)
+	if err != nil {
+		api.webhookError(w, r, err)
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusAccepted, map[string]any{
+		"data": map[string]any{
+			"delivery_id": delivery.ID,
+			"event_id":    delivery.EventID,
+			"type":        delivery.Topic,
+		},
+	})
+}
+
+// webhookRequest reads the webhook ID of the URL and the authenticated user,
+// writing the error response when either is missing.
+func (api *Api) webhookRequest(w http.ResponseWriter, r *http.Request) (uuid.UUID, uuid.UUID, bool) {
+	webhookID, err := uuid.Parse(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		utils.EncodeJSON(w, r, http.StatusBadRequest, map[string]string{
+			"error": "invalid webhook id",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, ok := api.authenticatedUserID(r)
+	if !ok {
+		utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+			"error": "unexpected internal server error",
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return webhookID, userID, true
+}
+
+func (api *Api) webhookError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, services.ErrWebhookNotFound) {
+		utils.EncodeJSON(w, r, http.StatusNotFound, map[string]string{
+			"error": "no webhook with given id",
+		})
+		return
+	}
+
+	utils.EncodeJSON(w, r, http.StatusInternalServerError, map[string]string{
+		"error": "unexpected internal server error",
+	})
+}
@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileMailer writes each email to Dir as an .eml file, which most mail
+// clients can open, instead of sending it. Meant for development and tests.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (m FileMailer) Send(_ context.Context, message Message) error {
+	body, err := encode(m.From, message)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), sanitize(message.To))
+	return os.WriteFile(filepath.Join(m.Dir, name), body, 0o644)
+}
+
+// sanitize keeps the characters of an address that are safe in a file name.
+func sanitize(value string) string {
+	b := []byte(value)
+	for i, c := range b {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '@', c == '.', c == '-', c == '_':
+		default:
+			b[i] = '_'
+		}
+	}
+
+	return string(b)
+}
@@ -0,0 +1,66 @@
+package mailer
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// localeFormat is how a locale writes the amounts and dates of its emails.
+// Dates are written in UTC.
+type localeFormat struct {
+	decimal   string
+	thousands string
+	date      string
+}
+
+// localeFormats holds the formats of the template locales. Other locales
+// use the format of defaultFormatLocale.
+var localeFormats = map[string]localeFormat{
+	"en":    {decimal: ".", thousands: ",", date: time.RFC1123},
+	"pt-BR": {decimal: ",", thousands: ".", date: "02/01/2006 15:04 MST"},
+}
+
+const defaultFormatLocale = "en"
+
+// templateFuncs returns the functions templates of locale use to format
+// values: "amount" writes a float64 with two decimals and "date" a
+// time.Time.
+func templateFuncs(locale string) map[string]any {
+	format, ok := localeFormats[locale]
+	if !ok {
+		format = localeFormats[defaultFormatLocale]
+	}
+
+	return map[string]any{
+		"amount": format.amount,
+		"date":   format.formatDate,
+	}
+}
+
+func (f localeFormat) amount(value float64) string {
+	cents := int64(math.Round(math.Abs(value) * 100))
+	units := strconv.FormatInt(cents/100, 10)
+
+	var b strings.Builder
+	if value < 0 && cents != 0 {
+		b.WriteByte('-')
+	}
+
+	for i, digit := range units {
+		if i > 0 && (len(units)-i)%3 == 0 {
+			b.WriteString(f.thousands)
+		}
+		b.WriteRune(digit)
+	}
+
+	b.WriteString(f.decimal)
+	b.WriteString(strconv.FormatInt(cents%100+100, 10)[1:])
+
+	return b.String()
+}
+
+func (f localeFormat) formatDate(t time.Time) string {
+	return t.UTC().Format(f.date)
+}
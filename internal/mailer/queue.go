@@ -0,0 +1,112 @@
+package mailer
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const (
+	QueueSize    = 256
+	QueueWorkers = 4
+	MaxAttempts  = 5
+	BaseBackoff  = 5 * time.Second
+	MaxBackoff   = 5 * time.Minute
+	SendTimeout  = 30 * time.Second
+)
+
+// Queue sends emails in the background through another Mailer, retrying
+// failed sends with exponential backoff up to MaxAttempts times. The queue
+// lives in memory: emails still queued when the process stops are lost.
+type Queue struct {
+	mailer Mailer
+	jobs   chan job
+}
+
+type job struct {
+	message  Message
+	attempts int
+}
+
+func NewQueue(mailer Mailer) *Queue {
+	return &Queue{
+		mailer: mailer,
+		jobs:   make(chan job, QueueSize),
+	}
+}
+
+// Send queues message. It fails with ErrQueueFull rather than wait for room.
+func (q *Queue) Send(_ context.Context, message Message) error {
+	return q.enqueue(job{message: message})
+}
+
+func (q *Queue) enqueue(j job) error {
+	select {
+	case q.jobs <- j:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Run sends the queued emails with QueueWorkers workers until ctx is done.
+func (q *Queue) Run(ctx context.Context) {
+	for range QueueWorkers {
+		go q.work(ctx)
+	}
+
+	<-ctx.Done()
+}
+
+func (q *Queue) work(ctx context.Context) {
+	for {
+		select {
+		case j := <-q.jobs:
+			q.send(ctx, j)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (q *Queue) send(ctx context.Context, j job) {
+	sendCtx, cancel := context.WithTimeout(ctx, SendTimeout)
+	err := q.mailer.Send(sendCtx, j.message)
+	cancel()
+	if err == nil {
+		return
+	}
+
+	j.attempts++
+	if j.attempts >= MaxAttempts {
+		slog.Error("Giving up on email", "To", j.message.To, "Subject", j.message.Subject, "Attempts", j.attempts, "Error", err)
+		return
+	}
+
+	wait := backoff(j.attempts)
+	slog.Warn("Failed to send email", "To", j.message.To, "Subject", j.message.Subject, "Attempts", j.attempts, "RetryIn", wait, "Error", err)
+
+	time.AfterFunc(wait, func() {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err := q.enqueue(j); err != nil {
+			slog.Error("Dropping email", "To", j.message.To, "Subject", j.message.Subject, "Error", err)
+		}
+	})
+}
+
+// backoff doubles the wait after each failed attempt, from BaseBackoff up to
+// MaxBackoff.
+func backoff(attempts int) time.Duration {
+	wait := BaseBackoff
+	for range attempts - 1 {
+		wait *= 2
+		if wait >= MaxBackoff {
+			return MaxBackoff
+		}
+	}
+
+	return wait
+}
@@ -0,0 +1,127 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveSMTP answers one SMTP conversation on listener, sending the received
+// message body to bodies.
+func serveSMTP(t *testing.T, listener net.Listener, bodies chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ready")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		switch command := strings.ToUpper(strings.Fields(line)[0]); command {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "MAIL", "RCPT":
+			reply("250 OK")
+		case "DATA":
+			reply("354 go ahead")
+
+			var body strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+
+				if line == ".\r\n" {
+					break
+				}
+				body.WriteString(line)
+			}
+
+			bodies <- body.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			t.Errorf("unexpected command %q", command)
+			reply("500 unknown")
+		}
+	}
+}
+
+func listen(t *testing.T) (net.Listener, SMTPMailer) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return listener, SMTPMailer{Host: host, Port: port, From: "Bid <no-reply@bid.example.com>"}
+}
+
+func TestSMTPMailerSends(t *testing.T) {
+	listener, mailer := listen(t)
+	bodies := make(chan string, 1)
+	go serveSMTP(t, listener, bodies)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := mailer.Send(ctx, Message{To: "ana@example.com", Subject: "Hello", Text: "Hi there\n"})
+	if err != nil {
+		t.Fatalf("send: %v", err)
+	}
+
+	if body := <-bodies; !strings.Contains(body, "Subject: Hello") || !strings.Contains(body, "Hi there") {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestSMTPMailerGivesUpOnHungServer(t *testing.T) {
+	listener, mailer := listen(t)
+
+	// The server accepts the connection but never greets.
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- mailer.Send(ctx, Message{To: "ana@example.com", Subject: "Hello", Text: "Hi\n"})
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("error = %v, want %v", err, context.DeadlineExceeded)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Send is still waiting on the server")
+	}
+}
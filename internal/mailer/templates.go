@@ -0,0 +1,125 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+var ErrTemplateNotFound = errors.New("email template not found")
+
+// Templates renders emails from the files under templates/<locale>/. Each
+// email has a <name>.txt file defining the "subject" and "text" templates,
+// and may have a <name>.html file with the HTML body. Locales missing an
+// email fall back to DefaultLocale. Templates write amounts and dates the way
+// their locale does with the "amount" and "date" functions.
+type Templates struct {
+	DefaultLocale string
+
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+func NewTemplates(defaultLocale string) (*Templates, error) {
+	t := &Templates{
+		DefaultLocale: defaultLocale,
+		text:          make(map[string]*texttemplate.Template),
+		html:          make(map[string]*htmltemplate.Template),
+	}
+
+	err := fs.WalkDir(templateFS, "templates", func(file string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		locale := path.Base(path.Dir(file))
+		ext := path.Ext(file)
+		key := locale + "/" + strings.TrimSuffix(path.Base(file), ext)
+		funcs := templateFuncs(locale)
+
+		switch ext {
+		case ".txt":
+			tmpl, err := texttemplate.New(path.Base(file)).Funcs(funcs).ParseFS(templateFS, file)
+			if err != nil {
+				return err
+			}
+
+			t.text[key] = tmpl
+		case ".html":
+			tmpl, err := htmltemplate.New(path.Base(file)).Funcs(funcs).ParseFS(templateFS, file)
+			if err != nil {
+				return err
+			}
+
+			t.html[key] = tmpl
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !t.HasLocale(defaultLocale) {
+		return nil, errors.New("no email templates for locale " + defaultLocale)
+	}
+
+	return t, nil
+}
+
+// HasLocale reports whether there are templates for locale.
+func (t *Templates) HasLocale(locale string) bool {
+	for key := range t.text {
+		if strings.HasPrefix(key, locale+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Render renders the email name in locale, or DefaultLocale when locale is
+// empty or has no such email. The recipient is left for the caller to set.
+func (t *Templates) Render(locale, name string, data any) (Message, error) {
+	key := locale + "/" + name
+	if _, ok := t.text[key]; !ok {
+		key = t.DefaultLocale + "/" + name
+	}
+
+	text, ok := t.text[key]
+	if !ok {
+		return Message{}, ErrTemplateNotFound
+	}
+
+	var message Message
+
+	var buf bytes.Buffer
+	if err := text.ExecuteTemplate(&buf, "subject", data); err != nil {
+		return Message{}, err
+	}
+	message.Subject = strings.TrimSpace(buf.String())
+
+	buf.Reset()
+	if err := text.ExecuteTemplate(&buf, "text", data); err != nil {
+		return Message{}, err
+	}
+	message.Text = strings.TrimSpace(buf.String()) + "\n"
+
+	if html, ok := t.html[key]; ok {
+		buf.Reset()
+		if err := html.Execute(&buf, data); err != nil {
+			return Message{}, err
+		}
+		message.HTML = buf.String()
+	}
+
+	return message, nil
+}
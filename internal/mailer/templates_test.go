@@ -0,0 +1,75 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderFormatsForLocale(t *testing.T) {
+	templates, err := NewTemplates("en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dueAt := time.Date(2026, time.March, 5, 11, 30, 0, 0, time.FixedZone("BRT", -3*60*60))
+	data := map[string]any{
+		"Username":     "ana",
+		"ProductName":  "Lamp",
+		"Amount":       1234.5,
+		"PaymentDueAt": dueAt,
+	}
+
+	tests := []struct {
+		locale string
+		want   []string
+	}{
+		{locale: "en", want: []string{"1,234.50", "Thu, 05 Mar 2026 14:30:00 UTC"}},
+		{locale: "pt-BR", want: []string{"1.234,50", "05/03/2026 14:30 UTC"}},
+		{locale: "fr", want: []string{"1,234.50", "Thu, 05 Mar 2026 14:30:00 UTC"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.locale, func(t *testing.T) {
+			message, err := templates.Render(test.locale, "payment_due", data)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+
+			for _, want := range test.want {
+				if !strings.Contains(message.Text, want) {
+					t.Errorf("text %q does not contain %q", message.Text, want)
+				}
+
+				if !strings.Contains(message.HTML, want) {
+					t.Errorf("html %q does not contain %q", message.HTML, want)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		value float64
+		en    string
+		ptBR  string
+	}{
+		{value: 0, en: "0.00", ptBR: "0,00"},
+		{value: 0.5, en: "0.50", ptBR: "0,50"},
+		{value: 999.999, en: "1,000.00", ptBR: "1.000,00"},
+		{value: 1234567.891, en: "1,234,567.89", ptBR: "1.234.567,89"},
+		{value: -42.1, en: "-42.10", ptBR: "-42,10"},
+		{value: -0.001, en: "0.00", ptBR: "0,00"},
+	}
+
+	for _, test := range tests {
+		if got := localeFormats["en"].amount(test.value); got != test.en {
+			t.Errorf("en amount(%v) = %q, want %q", test.value, got, test.en)
+		}
+
+		if got := localeFormats["pt-BR"].amount(test.value); got != test.ptBR {
+			t.Errorf("pt-BR amount(%v) = %q, want %q", test.value, got, test.ptBR)
+		}
+	}
+}
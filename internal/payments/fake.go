@@ -0,0 +1,236 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Payment methods understood by FakeProvider.
+const (
+	FakeCardSuccess  = "fake_card_success"
+	FakeCardDeclined = "fake_card_declined"
+	FakeCard3DS      = "fake_card_3ds"
+)
+
+const (
+	// FakeSignatureHeader carries "t=<unix timestamp>,v1=<hex HMAC-SHA256 of
+	// "timestamp.body">" on the webhooks of FakeProvider.
+	FakeSignatureHeader = "Fake-Signature"
+	// FakeSignatureTolerance is how old a webhook can be, to limit replays.
+	FakeSignatureTolerance = 5 * time.Minute
+	FakeWebhookTimeout     = 10 * time.Second
+)
+
+// FakeProvider is an in-process gateway for local testing. The payment
+// method picks the outcome: FakeCardSuccess is authorized right away,
+// FakeCardDeclined is declined and FakeCard3DS requires a challenge, passed
+// or failed with CompleteChallenge. Intents are kept in memory, and changes
+// made after an intent is created are posted to WebhookURL, signed with
+// Secret, like a real gateway would.
+type FakeProvider struct {
+	WebhookURL string
+	// ChallengeURL is where 3-D Secure challenges are served; the intent ID
+	// is appended to it.
+	ChallengeURL string
+	Secret       string
+
+	client     *http.Client
+	mu         sync.Mutex
+	intents    map[string]Intent
+	references map[string]string
+}
+
+func NewFakeProvider(webhookURL, challengeURL, secret string) *FakeProvider {
+	return &FakeProvider{
+		WebhookURL:   webhookURL,
+		ChallengeURL: strings.TrimSuffix(challengeURL, "/"),
+		Secret:       secret,
+		client:       &http.Client{Timeout: FakeWebhookTimeout},
+		intents:      make(map[string]Intent),
+		references:   make(map[string]string),
+	}
+}
+
+func (f *FakeProvider) Name() string {
+	return DriverFake
+}
+
+func (f *FakeProvider) CreateIntent(_ context.Context, params IntentParams) (Intent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if id, ok := f.references[params.Reference]; ok {
+		return f.intents[id], nil
+	}
+
+	intent := Intent{ID: "pi_fake_" + randomHex(12), Amount: params.Amount}
+	switch params.PaymentMethod {
+	case FakeCardSuccess:
+		intent.Status = StatusRequiresCapture
+	case FakeCard3DS:
+		intent.Status = StatusRequiresAction
+		intent.NextActionURL = f.ChallengeURL + "/" + intent.ID
+	case FakeCardDeclined:
+		intent.Status = StatusFailed
+		intent.FailureReason = "card declined"
+	default:
+		intent.Status = StatusFailed
+		intent.FailureReason = "unsupported payment method"
+	}
+
+	f.intents[intent.ID] = intent
+	f.references[params.Reference] = intent.ID
+
+	return intent, nil
+}
+
+func (f *FakeProvider) Capture(_ context.Context, intentID string) (Intent, error) {
+	return f.update(intentID, StatusRequiresCapture, func(intent *Intent) string {
+		intent.Status = StatusSucceeded
+		return EventIntentSucceeded
+	})
+}
+
+func (f *FakeProvider) Refund(_ context.Context, intentID string) (Intent, error) {
+	return f.update(intentID, StatusSucceeded, func(intent *Intent) string {
+		intent.Status = StatusRefunded
+		return EventIntentRefunded
+	})
+}
+
+// CompleteChallenge ends the 3-D Secure challenge of an intent, which is
+// then authorized if the customer passed it and failed otherwise.
+func (f *FakeProvider) CompleteChallenge(intentID string, passed bool) (Intent, error) {
+	return f.update(intentID, StatusRequiresAction, func(intent *Intent) string {
+		intent.NextActionURL = ""
+		if !passed {
+			intent.Status = StatusFailed
+			intent.FailureReason = "3-D Secure authentication failed"
+			return EventIntentFailed
+		}
+
+		intent.Status = StatusRequiresCapture
+		return EventIntentRequiresCapture
+	})
+}
+
+// update applies change to an intent in status from and sends the event it
+// returns.
+func (f *FakeProvider) update(intentID, from string, change func(*Intent) string) (Intent, error) {
+	f.mu.Lock()
+	intent, ok := f.intents[intentID]
+	if !ok {
+		f.mu.Unlock()
+		return Intent{}, ErrIntentNotFound
+	}
+
+	if intent.Status != from {
+		f.mu.Unlock()
+		return Intent{}, ErrInvalidIntentState
+	}
+
+	eventType := change(&intent)
+	f.intents[intentID] = intent
+	f.mu.Unlock()
+
+	go f.send(Event{
+		ID:        "evt_fake_" + randomHex(12),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Intent:    intent,
+	})
+
+	return intent, nil
+}
+
+// send posts an event to WebhookURL once. Unlike real gateways, failed
+// webhooks are not retried.
+func (f *FakeProvider) send(event Event) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		slog.Error("Failed to encode fake payment event", "ID", event.ID, "Error", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), FakeWebhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		slog.Error("Failed to send fake payment event", "ID", event.ID, "Error", err)
+		return
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(FakeSignatureHeader, fmt.Sprintf("t=%s,v1=%s", timestamp, f.sign(timestamp, body)))
+
+	res, err := f.client.Do(req)
+	if err != nil {
+		slog.Error("Failed to send fake payment event", "ID", event.ID, "Error", err)
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		slog.Error("Fake payment event was rejected", "ID", event.ID, "Status", res.StatusCode)
+	}
+}
+
+func (f *FakeProvider) VerifyWebhook(header http.Header, body []byte) (Event, error) {
+	var timestamp, signature string
+	for part := range strings.SplitSeq(header.Get(FakeSignatureHeader), ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || time.Since(time.Unix(unix, 0)).Abs() > FakeSignatureTolerance {
+		return Event{}, ErrInvalidSignature
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(f.sign(timestamp, body))) {
+		return Event{}, ErrInvalidSignature
+	}
+
+	var event Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		return Event{}, err
+	}
+
+	return event, nil
+}
+
+func (f *FakeProvider) sign(timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(f.Secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,86 @@
+// Package payments collects payments through a pluggable provider. A payment
+// starts as an intent, which the provider authorizes, possibly after the
+// customer passes a 3-D Secure challenge, and which is then captured. The
+// provider reports every later change of an intent through signed webhooks.
+package payments
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Drivers selectable with the PAYMENTS_DRIVER variable.
+const (
+	DriverFake = "fake"
+)
+
+// Statuses of an intent.
+const (
+	StatusRequiresAction  = "requires_action"
+	StatusRequiresCapture = "requires_capture"
+	StatusSucceeded       = "succeeded"
+	StatusFailed          = "failed"
+	StatusRefunded        = "refunded"
+)
+
+// Types of the webhook events, named after the status the intent moved to.
+const (
+	EventIntentRequiresCapture = "payment_intent.requires_capture"
+	EventIntentSucceeded       = "payment_intent.succeeded"
+	EventIntentFailed          = "payment_intent.failed"
+	EventIntentRefunded        = "payment_intent.refunded"
+)
+
+var (
+	ErrIntentNotFound     = errors.New("payment intent not found")
+	ErrInvalidIntentState = errors.New("payment intent can't do this in its current status")
+	ErrInvalidSignature   = errors.New("invalid webhook signature")
+)
+
+// IntentParams describe the payment to collect. Reference identifies the
+// payment on our side: creating an intent again with the same reference
+// returns the first one.
+type IntentParams struct {
+	Reference     string
+	Amount        float64
+	PaymentMethod string
+}
+
+// Intent is a payment as seen by the provider.
+type Intent struct {
+	ID     string  `json:"id"`
+	Status string  `json:"status"`
+	Amount float64 `json:"amount"`
+	// NextActionURL is the 3-D Secure challenge the customer must pass when
+	// Status is StatusRequiresAction.
+	NextActionURL string `json:"next_action_url,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// Event is a verified webhook event, carrying the intent as it was when the
+// event was sent.
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Intent    Intent    `json:"data"`
+}
+
+// Provider is a payment gateway.
+type Provider interface {
+	// Name identifies the provider in stored payments and events.
+	Name() string
+	// CreateIntent starts a payment. The intent comes back failed when the
+	// payment method is declined, and it still has to be captured once
+	// authorized.
+	CreateIntent(ctx context.Context, params IntentParams) (Intent, error)
+	// Capture collects an authorized intent.
+	Capture(ctx context.Context, intentID string) (Intent, error)
+	// Refund gives the whole amount of a captured intent back.
+	Refund(ctx context.Context, intentID string) (Intent, error)
+	// VerifyWebhook checks the signature of a webhook request and returns
+	// its event, or ErrInvalidSignature.
+	VerifyWebhook(header http.Header, body []byte) (Event, error)
+}
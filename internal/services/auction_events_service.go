@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// AuctionEventsService persists the room wide events of every auction, so a
+// client can catch up on events older than the in-memory log of its room.
+type AuctionEventsService struct {
+	pool    *pgxpool.Pool
+	queries *pg.Queries
+}
+
+func NewAuctionEventsService(pool *pgxpool.Pool) AuctionEventsService {
+	return AuctionEventsService{
+		pool:    pool,
+		queries: pg.New(pool),
+	}
+}
+
+// AppendEvent records a room wide event and returns its sequence number.
+// Instances serving the same auction append concurrently, so a number taken
+// in the meantime is retried.
+func (es *AuctionEventsService) AppendEvent(ctx context.Context, productID uuid.UUID, message Message) (uint64, error) {
+	message.Seq = 0
+	data, err := json.Marshal(message)
+	if err != nil {
+		return 0, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		seq, err := es.queries.AppendAuctionEvent(ctx, pg.AppendAuctionEventParams{
+			ProductID: productID,
+			Type:      EventName(message.Type),
+			Data:      data,
+		})
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == PgErrCodeUniqueViolation && attempt < MaxAppendEventAttempts {
+			continue
+		}
+
+		return uint64(seq), err
+	}
+}
+
+// EventsBetween returns at most MaxReplayEvents events of the auction
+// numbered after `after` and before `before`, in order.
+func (es *AuctionEventsService) EventsBetween(ctx context.Context, productID uuid.UUID, after, before uint64) ([]Message, error) {
+	rows, err := es.queries.ListAuctionEventsAfter(ctx, pg.ListAuctionEventsAfterParams{
+		ProductID: productID,
+		Seq:       int64(after),
+		Before:    int64(before),
+		MaxEvents: MaxReplayEvents,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]Message, 0, len(rows))
+	for _, row := range rows {
+		var message Message
+		if err := json.Unmarshal(row.Data, &message); err != nil {
+			return nil, err
+		}
+
+		message.Seq = uint64(row.Seq)
+
+		events = append(events, message)
+	}
+
+	return events, nil
+}
+
+// LastSeq returns the sequence number of the latest recorded event of the
+// auction, or 0 if there is none.
+func (es *AuctionEventsService) LastSeq(ctx context.Context, productID uuid.UUID) (uint64, error) {
+	seq, err := es.queries.GetLastAuctionEventSeq(ctx, productID)
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(seq), nil
+}
@@ -4,247 +4,814 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"slices"
 	"sync"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/gorilla/websocket"
+	"github.com/oThinas/bid/internal/store/pg"
 )
 
-type MessageType int
+// AuctionLobby holds the auction rooms open on this instance. Rooms are
+// opened on demand, so that every instance serving clients of an auction has
+// its own, and Bus ties the rooms of the same auction together.
+type AuctionLobby struct {
+	sync.Mutex
+	Rooms map[uuid.UUID]*AuctionRoom
+	// SlowConsumerPolicy is applied by the rooms opened in the lobby.
+	SlowConsumerPolicy SlowConsumerPolicy
+	ProductService     ProductFinder
+	BidsService        BidPlacer
+	EventsService      AuctionEventLog
+	SettlementService  AuctionSettler
+	// Bus relays room events and evictions between instances. Without it,
+	// the lobby serves a single instance.
+	Bus *AuctionBus
+	// Notifications gets the notifications relayed by Bus, and evicts users
+	// from the notification channel along with their auction rooms.
+	Notifications *NotificationHub
+}
 
-const (
-	// Requests
-	PlaceBid MessageType = iota
+// ProductFinder looks up the product of an auction, see ProductService.
+type ProductFinder interface {
+	GetProductByID(ctx context.Context, productID uuid.UUID) (pg.Product, error)
+}
 
-	// Success
-	SuccessfullyPlacedBid
+// BidPlacer places the bids of a room, see BidsService.
+type BidPlacer interface {
+	PlaceBid(ctx context.Context, productID, bidderID uuid.UUID, amount float64) (pg.Bid, *pg.Bid, error)
+}
 
-	// Info
-	NewBidPlaced
-	AuctionEnded
+// AuctionEventLog records the room wide events of an auction, see
+// AuctionEventsService.
+type AuctionEventLog interface {
+	AppendEvent(ctx context.Context, productID uuid.UUID, message Message) (uint64, error)
+	EventsBetween(ctx context.Context, productID uuid.UUID, after, before uint64) ([]Message, error)
+	LastSeq(ctx context.Context, productID uuid.UUID) (uint64, error)
+}
 
-	// Errors
-	FailedToPlaceBid
-	InvalidJSON
-)
+// AuctionSettler settles an auction once it ends, see SettlementService.
+type AuctionSettler interface {
+	SettleAuction(ctx context.Context, productID uuid.UUID) error
+}
 
-type Message struct {
-	Message string      `json:"message,omitempty"`
-	UserID  uuid.UUID   `json:"user_id,omitempty"`
-	Amount  float64     `json:"amount,omitempty"`
-	Type    MessageType `json:"type"`
+// Eviction asks a room to drop every connection owned by UserID, closing the
+// websocket with Reason.
+type Eviction struct {
+	UserID uuid.UUID `json:"user_id"`
+	Reason string    `json:"reason"`
 }
 
-type AuctionLobby struct {
-	sync.Mutex
-	Rooms map[uuid.UUID]*AuctionRoom
+// Subscription registers Client in a room. When ResumeFrom is set, the room
+// first replays the logged events with a greater sequence number.
+type Subscription struct {
+	Client     *Client
+	ResumeFrom uint64
 }
 
+// AuctionRoom fans bids out to everyone following an auction. Clients groups
+// the open connections by user, since a user can follow the same auction from
+// several tabs or devices at once.
+//
+// Room wide events (new bids, the end of the auction) are numbered with a
+// sequence and recorded through EventsService. The latest RoomEventLogSize are
+// also kept in memory, so that clients can catch up after reconnecting without
+// hitting the database. Events of the same auction published on other
+// instances come in through Relay, and how many users follow it there through
+// RemoteWatchers.
+//
+// Messages are handed to clients without blocking, see SlowConsumerPolicy.
 type AuctionRoom struct {
-	ID          uuid.UUID
-	Register    chan *Client
-	Unregister  chan *Client
-	Broadcast   chan Message
-	Context     context.Context
-	Clients     map[uuid.UUID]*Client
-	BidsService BidsService
+	ID             uuid.UUID
+	Register       chan Subscription
+	Unregister     chan *Client
+	Broadcast      chan Message
+	Evict          chan Eviction
+	Relay          chan Message
+	RemoteWatchers chan RemoteWatchers
+	Context        context.Context
+	Clients        map[uuid.UUID]map[*Client]struct{}
+	BidsService    BidPlacer
+	EventsService  AuctionEventLog
+	// SettlementService settles the auction when it ends on the leader.
+	SettlementService AuctionSettler
+	Bus               *AuctionBus
+
+	SlowConsumerPolicy SlowConsumerPolicy
+
+	seq    uint64
+	events []Message
+
+	// bidders holds when each recent bidder last bid, and presence the last
+	// presence sent, see Presence. remoteWatchers holds the watchers of the
+	// other instances, and watchersPublished the last count published for
+	// this one.
+	bidders             map[uuid.UUID]time.Time
+	presence            Presence
+	remoteWatchers      map[uuid.UUID]remoteCount
+	watchersPublished   int
+	watchersPublishedAt time.Time
+
+	// leader tells whether this instance holds the auction lease, and done
+	// is closed once Run returns.
+	leader bool
+	done   chan struct{}
 }
 
-type Client struct {
-	Conn   *websocket.Conn
-	Send   chan Message
-	Room   *AuctionRoom
-	UserID uuid.UUID
+// bidResult answers a bid placed through AuctionRoom.PlaceBid.
+type bidResult struct {
+	bid pg.Bid
+	err error
 }
 
-func NewAuctionRoom(ctx context.Context, id uuid.UUID, bidsService BidsService) *AuctionRoom {
+func NewAuctionRoom(ctx context.Context, id uuid.UUID, bidsService BidPlacer, eventsService AuctionEventLog) *AuctionRoom {
 	return &AuctionRoom{
-		ID:          id,
-		Register:    make(chan *Client),
-		Unregister:  make(chan *Client),
-		Broadcast:   make(chan Message),
-		Clients:     make(map[uuid.UUID]*Client),
-		Context:     ctx,
-		BidsService: bidsService,
+		ID:             id,
+		Register:       make(chan Subscription),
+		Unregister:     make(chan *Client),
+		Broadcast:      make(chan Message),
+		Evict:          make(chan Eviction),
+		Relay:          make(chan Message),
+		RemoteWatchers: make(chan RemoteWatchers),
+		Clients:        make(map[uuid.UUID]map[*Client]struct{}),
+		Context:        ctx,
+		BidsService:    bidsService,
+		EventsService:  eventsService,
+
+		SlowConsumerPolicy: SlowConsumerDropOldest,
+
+		bidders:        make(map[uuid.UUID]time.Time),
+		remoteWatchers: make(map[uuid.UUID]remoteCount),
+		done:           make(chan struct{}),
+	}
+}
+
+// Room returns the open room of the given product. A room whose auction
+// ended is not returned, even before it leaves the lobby.
+func (l *AuctionLobby) Room(productID uuid.UUID) (*AuctionRoom, bool) {
+	l.Lock()
+	defer l.Unlock()
+
+	room, ok := l.Rooms[productID]
+	if !ok || room.Context.Err() != nil {
+		return nil, false
+	}
+
+	return room, true
+}
+
+// Open returns the room of the product, opening it on this instance if the
+// auction is still running. The room runs until the auction ends, then
+// leaves the lobby.
+func (l *AuctionLobby) Open(ctx context.Context, productID uuid.UUID) (*AuctionRoom, error) {
+	if room, ok := l.Room(productID); ok {
+		return room, nil
+	}
+
+	product, err := l.ProductService.GetProductByID(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !product.AuctionEnd.After(time.Now()) {
+		return nil, ErrAuctionEnded
+	}
+
+	l.Lock()
+	defer l.Unlock()
+
+	if room, ok := l.Rooms[productID]; ok && room.Context.Err() == nil {
+		return room, nil
+	}
+
+	roomCtx, cancel := context.WithDeadline(context.Background(), product.AuctionEnd)
+	room := NewAuctionRoom(roomCtx, productID, l.BidsService, l.EventsService)
+	room.SlowConsumerPolicy = l.SlowConsumerPolicy
+	room.SettlementService = l.SettlementService
+	room.Bus = l.Bus
+	l.Rooms[productID] = room
+
+	go func() {
+		defer cancel()
+		defer l.Remove(room)
+		room.Run()
+	}()
+
+	return room, nil
+}
+
+// Remove takes room out of the lobby, unless it was already replaced.
+func (l *AuctionLobby) Remove(room *AuctionRoom) {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.Rooms[room.ID] == room {
+		delete(l.Rooms, room.ID)
+	}
+}
+
+// DisconnectUser evicts the user from every open auction room, on every
+// instance.
+func (l *AuctionLobby) DisconnectUser(userID uuid.UUID, reason string) {
+	eviction := Eviction{UserID: userID, Reason: reason}
+	l.evict(eviction)
+
+	if l.Bus == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), AuctionBusPublishTimeout)
+	defer cancel()
+
+	if err := l.Bus.Publish(ctx, BusEvent{Kind: BusEventEvict, Eviction: &eviction}); err != nil {
+		slog.Error("Failed to publish eviction", "User:", userID, "Error", err)
+	}
+}
+
+// evict evicts a user from the rooms and notification channel of this
+// instance.
+func (l *AuctionLobby) evict(eviction Eviction) {
+	if l.Notifications != nil {
+		l.Notifications.evict(eviction)
+	}
+
+	l.Lock()
+	rooms := make([]*AuctionRoom, 0, len(l.Rooms))
+	for _, room := range l.Rooms {
+		rooms = append(rooms, room)
+	}
+	l.Unlock()
+
+	for _, room := range rooms {
+		select {
+		case room.Evict <- eviction:
+		case <-room.Context.Done():
+		}
 	}
 }
 
-func NewClient(conn *websocket.Conn, room *AuctionRoom, userID uuid.UUID) *Client {
-	return &Client{
-		Conn:   conn,
-		Send:   make(chan Message, 512),
-		Room:   room,
+// dispatch hands an event published by another instance to the rooms of this
+// one.
+func (l *AuctionLobby) dispatch(event BusEvent) {
+	switch event.Kind {
+	case BusEventRoom, BusEventUser:
+		l.Lock()
+		room, ok := l.Rooms[event.ProductID]
+		l.Unlock()
+
+		if !ok {
+			return
+		}
+
+		message := event.Message
+		if message == nil && event.Seq != 0 {
+			message = l.loadEvent(event.ProductID, event.Seq)
+		}
+
+		if message == nil {
+			return
+		}
+
+		select {
+		case room.Relay <- *message:
+		case <-room.done:
+		}
+
+	case BusEventPresence:
+		l.Lock()
+		room, ok := l.Rooms[event.ProductID]
+		l.Unlock()
+
+		if !ok || event.Presence == nil {
+			return
+		}
+
+		select {
+		case room.RemoteWatchers <- RemoteWatchers{Instance: event.Origin, Count: event.Presence.Watchers}:
+		case <-room.done:
+		}
+
+	case BusEventEvict:
+		if event.Eviction != nil {
+			l.evict(*event.Eviction)
+		}
+
+	case BusEventNotification:
+		if l.Notifications != nil && event.Notification != nil {
+			l.Notifications.deliver(*event.Notification)
+		}
+	}
+}
+
+// loadEvent reads back a room event published by sequence number, see
+// busPayload.
+func (l *AuctionLobby) loadEvent(productID uuid.UUID, seq uint64) *Message {
+	ctx, cancel := context.WithTimeout(context.Background(), RoomEventReadTimeout)
+	defer cancel()
+
+	events, err := l.EventsService.EventsBetween(ctx, productID, seq-1, seq+1)
+	if err != nil || len(events) == 0 {
+		slog.Error("Failed to load relayed auction event", "AuctionID", productID, "Seq", seq, "Error", err)
+		return nil
+	}
+
+	return &events[0]
+}
+
+// PlaceBid places a bid through the room, so it is ordered with the bids sent
+// over websockets and broadcast to everyone following the auction.
+func (r *AuctionRoom) PlaceBid(ctx context.Context, userID uuid.UUID, amount float64) (pg.Bid, error) {
+	result := make(chan bidResult, 1)
+	message := Message{
+		Type:   PlaceBid,
 		UserID: userID,
+		Amount: amount,
+		result: result,
+	}
+
+	select {
+	case r.Broadcast <- message:
+	case <-r.Context.Done():
+		return pg.Bid{}, ErrAuctionEnded
+	case <-ctx.Done():
+		return pg.Bid{}, ctx.Err()
+	}
+
+	select {
+	case res := <-result:
+		return res.bid, res.err
+	case <-ctx.Done():
+		return pg.Bid{}, ctx.Err()
 	}
 }
 
 func (r *AuctionRoom) Run() {
+	defer close(r.done)
 	slog.Info("Auction has begun", "AuctionID", r.ID)
 
-	defer func() {
-		close(r.Broadcast)
-		close(r.Register)
-		close(r.Unregister)
-	}()
+	// Keep numbering after the recorded events, in case the room is opened
+	// again for the same auction.
+	seq, err := r.EventsService.LastSeq(r.Context, r.ID)
+	if err != nil {
+		slog.Error("Failed to load the last auction event", "AuctionID", r.ID, "Error", err)
+	}
+	r.seq = seq
+
+	presenceTicker := time.NewTicker(PresenceInterval)
+	defer presenceTicker.Stop()
+
+	// Rooms without a deadline have no countdown and never tick.
+	var tickTimer *time.Timer
+	var ticks <-chan time.Time
+	if _, ok := r.Context.Deadline(); ok {
+		tickTimer = time.NewTimer(r.nextTick())
+		defer tickTimer.Stop()
+		ticks = tickTimer.C
+	}
 
+	// Every instance competes for the lease from the start, so the leader is
+	// known well before the deadline.
+	var leases <-chan time.Time
+	if r.Bus != nil {
+		r.lead()
+		leaseTicker := time.NewTicker(AuctionLeaseInterval)
+		defer leaseTicker.Stop()
+		leases = leaseTicker.C
+	}
+
+	// The room channels are never closed: clients may still be trying to
+	// reach a finished room and select on Context.Done() instead.
 	for {
 		select {
-		case client := <-r.Register:
-			r.registerClient(client)
+		case subscription := <-r.Register:
+			r.registerClient(subscription)
 		case client := <-r.Unregister:
 			r.unregisterClient(client)
 		case message := <-r.Broadcast:
 			r.broadcastMessage(message)
+		case eviction := <-r.Evict:
+			r.evictUser(eviction)
+		case message := <-r.Relay:
+			if r.relay(message) {
+				r.close(message)
+				return
+			}
+		case remote := <-r.RemoteWatchers:
+			r.trackRemoteWatchers(remote)
+		case <-presenceTicker.C:
+			r.broadcastPresence()
+		case <-ticks:
+			r.broadcastTick()
+			tickTimer.Reset(r.nextTick())
+		case <-leases:
+			r.lead()
 		case <-r.Context.Done():
+			r.close(r.endAuction())
+			return
+		}
+	}
+}
+
+// lead renews the auction lease of this instance and reports whether it is
+// the leader. Without a bus, the room is always the leader.
+func (r *AuctionRoom) lead() bool {
+	if r.Bus == nil {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context), AuctionBusPublishTimeout)
+	defer cancel()
+
+	leader, err := r.Bus.Lead(ctx, r.ID)
+	if err != nil {
+		slog.Error("Failed to renew auction lease", "AuctionID", r.ID, "Error", err)
+		return false
+	}
+
+	if leader != r.leader {
+		slog.Info("Auction leadership changed", "AuctionID", r.ID, "Leader", leader)
+	}
+
+	r.leader = leader
+	return leader
+}
+
+// endAuction returns the event ending the auction. The leader settles the
+// auction, then records and publishes the event; the other instances wait for
+// it, taking over once the lease of a vanished leader expires, which is also
+// how they learn of an end the leader failed to record.
+func (r *AuctionRoom) endAuction() Message {
+	ticker := time.NewTicker(AuctionLeaseInterval)
+	defer ticker.Stop()
+
+	for {
+		if r.lead() {
 			slog.Info("Auction has ended", "AuctionID", r.ID)
-			for _, client := range r.Clients {
-				client.Send <- Message{
-					Message: "Auction has ended",
-					Type:    AuctionEnded,
-				}
+			r.settle()
+
+			ended := Message{
+				Message: "Auction has ended",
+				Type:    AuctionEnded,
+			}
+			if recorded, err := r.record(ended); err == nil {
+				return recorded
 			}
 
-			return
+			ended.ProductID = r.ID
+			return ended
 		}
+
+		select {
+		case message := <-r.Relay:
+			if r.relay(message) {
+				return message
+			}
+		case <-r.RemoteWatchers:
+		case <-ticker.C:
+		}
+	}
+}
+
+// settle settles the ended auction. On failure, SettlementService.Run tries
+// again later.
+func (r *AuctionRoom) settle() {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context), RoomEventWriteTimeout)
+	defer cancel()
+
+	if err := r.SettlementService.SettleAuction(ctx, r.ID); err != nil {
+		slog.Error("Failed to settle auction", "AuctionID", r.ID, "Error", err)
 	}
 }
 
-func (r *AuctionRoom) registerClient(client *Client) {
-	slog.Info("New user connected", "Client:", client)
-	r.Clients[client.UserID] = client
+// close tells every client the auction ended. Single auction connections
+// close themselves with a normal close frame once the event is written, and
+// multiplexed ones forget the room. The event goes through the control lane
+// of the clients, so it reaches them even when they lag behind.
+func (r *AuctionRoom) close(ended Message) {
+	r.fanOut(ended, uuid.Nil)
+	clear(r.Clients)
 }
 
-func (r *AuctionRoom) unregisterClient(client *Client) {
-	slog.Info("User disconnected", "Client:", client)
-	delete(r.Clients, client.UserID)
+func (r *AuctionRoom) registerClient(subscription Subscription) {
+	client := subscription.Client
+	slog.Info("New user connected", "Room:", r.ID, "User:", client.UserID)
+	connections, ok := r.Clients[client.UserID]
+	if !ok {
+		connections = make(map[*Client]struct{})
+		r.Clients[client.UserID] = connections
+	}
+
+	connections[client] = struct{}{}
+
+	r.sendSnapshot(client)
+	r.replay(client, subscription.ResumeFrom)
 }
 
-func (r *AuctionRoom) broadcastMessage(message Message) {
-	slog.Info("New message received", "Room:", r.ID, "User:", message.UserID, "Message:", message.Message)
-	switch message.Type {
-	case PlaceBid:
-		bid, err := r.BidsService.PlaceBid(r.Context, r.ID, message.UserID, message.Amount)
+// replay sends client the events numbered after resumeFrom, before any live
+// event. Events older than the in-memory log are read back from the
+// database. The replay is queued at once, before the client drains anything,
+// so it is limited to MaxReplayEvents and to half the room left in the Send
+// buffer, which multiplexed clients share with their other rooms: a client
+// that missed more is sent a Resync instead, so that it reloads the auction
+// rather than miss events.
+func (r *AuctionRoom) replay(client *Client, resumeFrom uint64) {
+	if resumeFrom == 0 || resumeFrom >= r.seq {
+		return
+	}
+
+	missed := r.seq - resumeFrom
+	free := cap(client.Send) - len(client.Send)
+	if missed > MaxReplayEvents || missed > uint64(free/2) {
+		r.resync(client, "too many events were missed")
+		return
+	}
+
+	oldest := r.seq + 1
+	if len(r.events) > 0 {
+		oldest = r.events[0].Seq
+	}
+
+	if resumeFrom+1 < oldest {
+		events, err := r.EventsService.EventsBetween(r.Context, r.ID, resumeFrom, oldest)
 		if err != nil {
-			if errors.Is(err, ErrBidAmountTooLow) {
-				if client, ok := r.Clients[message.UserID]; ok {
-					client.Send <- Message{
-						Message: ErrBidAmountTooLow.Error(),
-						Type:    FailedToPlaceBid,
-						UserID:  message.UserID,
-					}
-				}
+			slog.Error("Failed to load auction events", "Room:", r.ID, "Error", err)
+			r.resync(client, "missed events are unavailable")
+			return
+		}
 
+		for _, event := range events {
+			if !r.deliver(client, event) {
 				return
 			}
 		}
+	}
 
-		if client, ok := r.Clients[message.UserID]; ok {
-			client.Send <- Message{
-				Message: "Your bid was successfully placed",
-				Type:    SuccessfullyPlacedBid,
-				UserID:  message.UserID,
-			}
+	for _, event := range r.events {
+		if event.Seq > resumeFrom && !r.deliver(client, event) {
+			return
 		}
+	}
+}
 
-		for id, client := range r.Clients {
-			newBidMessage := Message{
-				Message: "A new bid was placed",
-				Type:    NewBidPlaced,
-				Amount:  bid.Amount,
-				UserID:  message.UserID,
-			}
-
-			if id == message.UserID {
-				continue
-			}
+// resync tells client to reload the auction and resume from the latest
+// event, since the events it missed can't be replayed.
+func (r *AuctionRoom) resync(client *Client, reason string) {
+	r.deliver(client, Message{
+		Message:   reason,
+		Type:      Resync,
+		ProductID: r.ID,
+		Seq:       r.seq,
+	})
+}
 
-			client.Send <- newBidMessage
+// resyncAll sends a Resync to every client of the room.
+func (r *AuctionRoom) resyncAll(reason string) {
+	for _, connections := range r.Clients {
+		for client := range connections {
+			r.resync(client, reason)
 		}
+	}
+}
 
-	case InvalidJSON:
-		client, ok := r.Clients[message.UserID]
-		if !ok {
-			slog.Info("Client not found", "UserID", message.UserID)
-			return
+// unregisterClient removes only the given connection, leaving the user's
+// other connections untouched.
+func (r *AuctionRoom) unregisterClient(client *Client) {
+	slog.Info("User disconnected", "Room:", r.ID, "User:", client.UserID)
+	r.dropClient(client)
+}
+
+func (r *AuctionRoom) evictUser(eviction Eviction) {
+	connections, ok := r.Clients[eviction.UserID]
+	if !ok {
+		return
+	}
+
+	slog.Info("Evicting user", "Room:", r.ID, "User:", eviction.UserID, "Reason:", eviction.Reason)
+	delete(r.Clients, eviction.UserID)
+	for client := range connections {
+		r.deliver(client, Message{
+			Message:   eviction.Reason,
+			Type:      ConnectionClosed,
+			UserID:    eviction.UserID,
+			ProductID: r.ID,
+		})
+	}
+}
+
+// sendToUser delivers a personal message to every connection of userID.
+// Feed clients only follow room wide events and are skipped.
+func (r *AuctionRoom) sendToUser(userID uuid.UUID, message Message) {
+	message.ProductID = r.ID
+	for client := range r.Clients[userID] {
+		if client.Feed {
+			continue
 		}
 
-		client.Send <- message
+		r.deliver(client, message)
 	}
 }
 
-func (c *Client) ReadEventLoop() {
-	defer func() {
-		c.Room.Unregister <- c
-		c.Conn.Close()
-	}()
+// notifyUser sends a personal message to message.UserID, on every instance.
+// Unlike room wide events, it is neither numbered nor logged.
+func (r *AuctionRoom) notifyUser(message Message) {
+	r.sendToUser(message.UserID, message)
 
-	c.Conn.SetReadLimit(MaxMessageSize)
-	c.Conn.SetReadDeadline(time.Now().Add(ReadDeadline))
-	c.Conn.SetPongHandler(func(string) error {
-		c.Conn.SetReadDeadline(time.Now().Add(ReadDeadline))
-		return nil
-	})
+	if r.Bus == nil {
+		return
+	}
 
-	for {
-		var message Message
-		message.UserID = c.UserID
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context), AuctionBusPublishTimeout)
+	defer cancel()
 
-		err := c.Conn.ReadJSON(&message)
-		if err != nil {
-			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				slog.Error("Unexpected close error", "Error", err)
-				return
-			}
+	message.ProductID = r.ID
+	event := BusEvent{Kind: BusEventUser, ProductID: r.ID, Message: &message}
+	if err := r.Bus.Publish(ctx, event); err != nil {
+		slog.Error("Failed to publish personal message", "Room:", r.ID, "User:", message.UserID, "Error", err)
+	}
+}
 
-			c.Room.Broadcast <- Message{
-				Message: "Invalid JSON",
-				Type:    InvalidJSON,
-				UserID:  message.UserID,
-			}
+// publish records a room wide event, then delivers it to every client. The
+// websocket connections of except are skipped, since they already got a
+// personal message about the same event. An event that can't be recorded is
+// not numbered, since a made up number could collide with one recorded by
+// another instance: the clients of every instance are sent a Resync instead.
+func (r *AuctionRoom) publish(message Message, except uuid.UUID) {
+	recorded, err := r.record(message)
+	if err == nil {
+		r.fanOut(recorded, except)
+		return
+	}
 
-			continue
+	resync := Message{Message: "the latest events are unavailable", Type: Resync}
+	r.resyncAll(resync.Message)
+
+	if r.Bus == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context), AuctionBusPublishTimeout)
+	defer cancel()
+
+	resync.ProductID = r.ID
+	event := BusEvent{Kind: BusEventRoom, ProductID: r.ID, Message: &resync}
+	if err := r.Bus.Publish(ctx, event); err != nil {
+		slog.Error("Failed to publish resync", "Room:", r.ID, "Error", err)
+	}
+}
+
+// record numbers a room wide event, logs it and publishes it to the other
+// instances.
+func (r *AuctionRoom) record(message Message) (Message, error) {
+	message.ProductID = r.ID
+
+	// The room context is already done when the auction ends, but its last
+	// event must still be recorded.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context), RoomEventWriteTimeout)
+	defer cancel()
+
+	seq, err := r.EventsService.AppendEvent(ctx, r.ID, message)
+	if err != nil {
+		slog.Error("Failed to record auction event", "Room:", r.ID, "Error", err)
+		return Message{}, err
+	}
+
+	message.Seq = seq
+	r.logEvent(message)
+
+	if r.Bus != nil {
+		event := BusEvent{Kind: BusEventRoom, ProductID: r.ID, Message: &message}
+		if err := r.Bus.Publish(ctx, event); err != nil {
+			slog.Error("Failed to publish auction event", "Room:", r.ID, "Seq", message.Seq, "Error", err)
 		}
+	}
+
+	return message, nil
+}
+
+// relay handles an event published by another instance. It reports whether
+// the event ends the auction, leaving its delivery to close.
+func (r *AuctionRoom) relay(message Message) bool {
+	// Personal messages, see notifyUser, and resyncs, see publish, are not
+	// numbered.
+	if message.Seq == 0 {
+		if message.Type == Resync {
+			r.resyncAll(message.Message)
+		} else {
+			r.sendToUser(message.UserID, message)
+		}
+
+		return false
+	}
 
-		c.Room.Broadcast <- message
+	r.logEvent(message)
+	if message.Type == AuctionEnded {
+		return true
 	}
+
+	if message.Type == NewBidPlaced {
+		r.trackBidder(message.UserID)
+	}
+
+	r.fanOut(message, uuid.Nil)
+	return false
 }
 
-func (c *Client) WriteEventLoop() {
-	ticker := time.NewTicker(PingInterval)
-	defer func() {
-		ticker.Stop()
-		c.Conn.Close()
-	}()
+// logEvent keeps message in the in-memory log, ordered by sequence since
+// events from other instances may come in late.
+func (r *AuctionRoom) logEvent(message Message) {
+	r.seq = max(r.seq, message.Seq)
 
-	for {
-		select {
-		case <-ticker.C:
-			c.Conn.SetWriteDeadline(time.Now().Add(WriteDeadLine))
-			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
-				slog.Error("Unexpected write error", "Error", err)
-				return
-			}
+	i := len(r.events)
+	for i > 0 && r.events[i-1].Seq > message.Seq {
+		i--
+	}
 
-		case message, ok := <-c.Send:
-			if !ok {
-				c.Conn.WriteJSON(Message{
-					Message: "Closing websocket connection",
-					Type:    websocket.CloseMessage,
-				})
-				return
-			}
+	r.events = slices.Insert(r.events, i, message)
+	if len(r.events) > RoomEventLogSize {
+		r.events = r.events[len(r.events)-RoomEventLogSize:]
+	}
+}
 
-			if message.Type == AuctionEnded {
-				close(c.Send)
-				return
+// fanOut delivers a room wide event to every client but the websocket
+// connections of except.
+func (r *AuctionRoom) fanOut(message Message, except uuid.UUID) {
+	for userID, connections := range r.Clients {
+		for client := range connections {
+			if userID == except && !client.Feed {
+				continue
 			}
 
-			c.Conn.SetWriteDeadline(time.Now().Add(WriteDeadLine))
-			if err := c.Conn.WriteJSON(message); err != nil {
-				c.Room.Unregister <- c
-				return
-			}
+			r.deliver(client, message)
 		}
 	}
 }
+
+func (r *AuctionRoom) broadcastMessage(message Message) {
+	slog.Info("New message received", "Room:", r.ID, "User:", message.UserID, "Message:", message.Message)
+	switch message.Type {
+	case PlaceBid:
+		// The deadline may pass while the bid waits to be picked up.
+		if r.Context.Err() != nil {
+			r.rejectBid(message, ErrAuctionEnded)
+			return
+		}
+
+		bid, outbid, err := r.BidsService.PlaceBid(r.Context, r.ID, message.UserID, message.Amount)
+		if err != nil {
+			r.rejectBid(message, err)
+			return
+		}
+
+		if message.result != nil {
+			message.result <- bidResult{bid: bid}
+		}
+
+		r.trackBidder(message.UserID)
+
+		r.sendToUser(message.UserID, Message{
+			Message:   "Your bid was successfully placed",
+			Type:      SuccessfullyPlacedBid,
+			UserID:    message.UserID,
+			Amount:    bid.Amount,
+			RequestID: message.RequestID,
+			BidID:     bid.ID,
+		})
+
+		if outbid != nil {
+			r.notifyUser(Message{
+				Message: "You have been outbid",
+				Type:    Outbid,
+				UserID:  outbid.BidderID,
+				Amount:  bid.Amount,
+			})
+		}
+
+		r.publish(Message{
+			Message: "A new bid was placed",
+			Type:    NewBidPlaced,
+			Amount:  bid.Amount,
+			UserID:  message.UserID,
+		}, message.UserID)
+	}
+}
+
+// rejectBid answers a bid that could not be placed.
+func (r *AuctionRoom) rejectBid(message Message, err error) {
+	if message.result != nil {
+		message.result <- bidResult{err: err}
+	}
+
+	reason := "could not place bid, try again later"
+	if errors.Is(err, ErrBidAmountTooLow) || errors.Is(err, ErrAuctionEnded) {
+		reason = err.Error()
+	} else {
+		slog.Error("Failed to place bid", "Room:", r.ID, "Error", err)
+	}
+
+	r.sendToUser(message.UserID, Message{
+		Message:   reason,
+		Type:      FailedToPlaceBid,
+		UserID:    message.UserID,
+		RequestID: message.RequestID,
+	})
+}
@@ -0,0 +1,373 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/oThinas/bid/internal/store/pg"
+	"go.uber.org/goleak"
+)
+
+type fakeProducts struct {
+	product pg.Product
+}
+
+func (f fakeProducts) GetProductByID(context.Context, uuid.UUID) (pg.Product, error) {
+	return f.product, nil
+}
+
+func TestAuctionRoomLifecycle(t *testing.T) {
+	tests := []struct {
+		name string
+		// auctionIn is how long the auction runs, and closeSocket whether
+		// the client hangs up before it ends.
+		auctionIn   time.Duration
+		closeSocket bool
+	}{
+		{name: "auction ends", auctionIn: 100 * time.Millisecond},
+		{name: "socket closes", auctionIn: time.Second, closeSocket: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+			product := pg.Product{ID: uuid.New(), AuctionEnd: time.Now().Add(test.auctionIn)}
+			lobby := &AuctionLobby{
+				Rooms:             make(map[uuid.UUID]*AuctionRoom),
+				ProductService:    fakeProducts{product: product},
+				BidsService:       fakeBids{},
+				EventsService:     &fakeEvents{},
+				SettlementService: fakeSettler{},
+			}
+
+			room, err := lobby.Open(context.Background(), product.ID)
+			if err != nil {
+				t.Fatalf("open room: %v", err)
+			}
+
+			// exited is closed once both pumps of the client returned.
+			exited := make(chan struct{})
+			upgrader := websocket.Upgrader{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				conn, err := upgrader.Upgrade(w, r, nil)
+				if err != nil {
+					t.Errorf("upgrade: %v", err)
+					return
+				}
+
+				client := NewClient(conn, lobby, uuid.New())
+				if err := client.Subscribe(room); err != nil {
+					t.Errorf("subscribe: %v", err)
+					conn.Close()
+					return
+				}
+
+				var pumps sync.WaitGroup
+				pumps.Add(2)
+				go func() {
+					defer pumps.Done()
+					client.ReadEventLoop()
+				}()
+				go func() {
+					defer pumps.Done()
+					client.WriteEventLoop()
+				}()
+				go func() {
+					pumps.Wait()
+					close(exited)
+				}()
+			}))
+			defer server.Close()
+
+			conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer conn.Close()
+
+			if test.closeSocket {
+				conn.Close()
+			} else {
+				for {
+					if _, _, err = conn.ReadMessage(); err != nil {
+						break
+					}
+				}
+
+				if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+					t.Errorf("read error = %v, want a normal close", err)
+				}
+			}
+
+			select {
+			case <-exited:
+			case <-time.After(testTimeout):
+				t.Fatal("timed out waiting for the client pumps to exit")
+			}
+
+			if test.closeSocket && room.Context.Err() != nil {
+				t.Error("the auction ended before the pumps exited")
+			}
+
+			select {
+			case <-room.done:
+			case <-time.After(test.auctionIn + testTimeout):
+				t.Fatal("timed out waiting for the room to stop")
+			}
+
+			waitFor(t, "the room to leave the lobby", func() bool {
+				lobby.Lock()
+				defer lobby.Unlock()
+
+				_, ok := lobby.Rooms[product.ID]
+				return !ok
+			})
+		})
+	}
+}
+
+func TestAuctionRoomReplay(t *testing.T) {
+	const logged = RoomEventLogSize + MaxReplayEvents + 10
+
+	tests := []struct {
+		name       string
+		logged     uint64
+		live       uint64
+		queued     int
+		resumeFrom uint64
+		replayed   int
+		resync     bool
+	}{
+		{
+			name:       "recent events from the database",
+			logged:     logged,
+			resumeFrom: logged - 5,
+			replayed:   5,
+		},
+		{
+			name:       "as many events from the database as replayed at once",
+			logged:     logged,
+			resumeFrom: logged - MaxReplayEvents,
+			replayed:   MaxReplayEvents,
+		},
+		{
+			name:       "too many events in the database",
+			logged:     logged,
+			resumeFrom: logged - MaxReplayEvents - 1,
+			resync:     true,
+		},
+		{
+			name:       "from the first event",
+			logged:     logged,
+			resumeFrom: 1,
+			resync:     true,
+		},
+		{
+			name:       "events from the database and memory",
+			logged:     logged,
+			live:       20,
+			resumeFrom: logged - 30,
+			replayed:   50,
+		},
+		{
+			name:       "as many events from a full log as replayed at once",
+			live:       RoomEventLogSize,
+			resumeFrom: RoomEventLogSize - MaxReplayEvents,
+			replayed:   MaxReplayEvents,
+		},
+		{
+			name:       "too many events in a full log",
+			live:       RoomEventLogSize,
+			resumeFrom: RoomEventLogSize - MaxReplayEvents - 1,
+			resync:     true,
+		},
+		{
+			name:       "more events than the send buffer has room for",
+			live:       20,
+			queued:     ClientSendBufferSize - 20,
+			resumeFrom: 10,
+			resync:     true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			events := &fakeEvents{}
+			for range test.logged {
+				events.AppendEvent(context.Background(), uuid.Nil, Message{Type: NewBidPlaced, Amount: 1})
+			}
+
+			room, _ := startRoomWithEvents(t, SlowConsumerDropOldest, events)
+
+			ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+			defer cancel()
+
+			// Live bids fill the in-memory log of the room.
+			for i := range test.live {
+				if _, err := room.PlaceBid(ctx, uuid.New(), float64(i+2)); err != nil {
+					t.Fatalf("place bid: %v", err)
+				}
+			}
+
+			client := NewFeedClient(nil, uuid.New())
+			for range test.queued {
+				client.Send <- Message{Type: PresenceUpdate}
+			}
+
+			if err := client.SubscribeFrom(room, test.resumeFrom); err != nil {
+				t.Fatalf("subscribe: %v", err)
+			}
+
+			// The live event marks the end of the replay.
+			last := test.logged + test.live
+			if _, err := room.PlaceBid(ctx, uuid.New(), float64(last+2)); err != nil {
+				t.Fatalf("place bid: %v", err)
+			}
+
+			var replayed []Message
+			var resync *Message
+			for {
+				message := receive(t, client.Send, NewBidPlaced, Resync)
+				if message.Type == Resync {
+					resync = &message
+					continue
+				}
+
+				if message.Seq == last+1 {
+					break
+				}
+
+				replayed = append(replayed, message)
+			}
+
+			if (resync != nil) != test.resync {
+				t.Fatalf("resync = %+v, want one %v", resync, test.resync)
+			}
+
+			if resync != nil && resync.Seq != last {
+				t.Errorf("resync seq = %d, want %d", resync.Seq, last)
+			}
+
+			if len(replayed) != test.replayed {
+				t.Fatalf("replayed %d events, want %d", len(replayed), test.replayed)
+			}
+
+			for i, message := range replayed {
+				if want := test.resumeFrom + uint64(i) + 1; message.Seq != want {
+					t.Errorf("replayed[%d] seq = %d, want %d", i, message.Seq, want)
+				}
+			}
+		})
+	}
+}
+
+// waitFor polls condition until it holds, failing the test after
+// testTimeout.
+func waitFor(t *testing.T, what string, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(testTimeout)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAuctionRoomUnrecordedEvent(t *testing.T) {
+	events := &fakeEvents{}
+	room, _ := startRoomWithEvents(t, SlowConsumerDropOldest, events)
+
+	client := NewFeedClient(nil, uuid.New())
+	if err := client.Subscribe(room); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+	defer cancel()
+
+	if _, err := room.PlaceBid(ctx, uuid.New(), 1); err != nil {
+		t.Fatalf("place bid: %v", err)
+	}
+
+	recorded := receive(t, client.Send, NewBidPlaced)
+
+	events.mu.Lock()
+	events.appendErr = errors.New("database unavailable")
+	events.mu.Unlock()
+
+	if _, err := room.PlaceBid(ctx, uuid.New(), 2); err != nil {
+		t.Fatalf("place bid: %v", err)
+	}
+
+	message := receive(t, client.Send, NewBidPlaced, Resync)
+	if message.Type != Resync {
+		t.Fatalf("got %+v, want a resync", message)
+	}
+
+	if message.Seq != recorded.Seq {
+		t.Errorf("resync seq = %d, want the last recorded event %d", message.Seq, recorded.Seq)
+	}
+
+	events.mu.Lock()
+	events.appendErr = nil
+	events.mu.Unlock()
+
+	if _, err := room.PlaceBid(ctx, uuid.New(), 3); err != nil {
+		t.Fatalf("place bid: %v", err)
+	}
+
+	if next := receive(t, client.Send, NewBidPlaced); next.Seq != recorded.Seq+1 || next.Amount != 3 {
+		t.Errorf("next event = %+v, want the bid of 3 numbered %d", next, recorded.Seq+1)
+	}
+}
+
+func TestLobbyLoadsEventsRelayedBySeq(t *testing.T) {
+	events := &fakeEvents{}
+	room, _ := startRoomWithEvents(t, SlowConsumerDropOldest, events)
+	lobby := &AuctionLobby{
+		Rooms:         map[uuid.UUID]*AuctionRoom{room.ID: room},
+		EventsService: events,
+	}
+
+	client := NewFeedClient(nil, uuid.New())
+	if err := client.Subscribe(room); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	// Another instance recorded a bid too large to be sent whole.
+	bid := Message{Type: NewBidPlaced, ProductID: room.ID, Amount: 5, Message: strings.Repeat("x", MaxBusPayloadSize)}
+	seq, err := events.AppendEvent(context.Background(), room.ID, bid)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bid.Seq = seq
+	payload, err := busPayload(BusEvent{Kind: BusEventRoom, ProductID: room.ID, Message: &bid})
+	if err != nil {
+		t.Fatalf("busPayload: %v", err)
+	}
+
+	var event BusEvent
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		t.Fatal(err)
+	}
+
+	lobby.dispatch(event)
+
+	if got := receive(t, client.Send, NewBidPlaced); got.Seq != seq || got.Amount != 5 {
+		t.Errorf("relayed event = %+v, want the bid of 5 numbered %d", got, seq)
+	}
+}
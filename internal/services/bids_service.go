@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -22,33 +23,85 @@ func NewBidsService(pool *pgxpool.Pool) BidsService {
 	}
 }
 
-func (bs *BidsService) PlaceBid(ctx context.Context, productID, bidderID uuid.UUID, amount float64) (pg.Bid, error) {
-	product, err := bs.queries.GetProductByID(ctx, productID)
+// PlaceBid records a bid if it beats the base price and the highest bid. The
+// product row is locked meanwhile, so bids accepted by other instances for
+// the same auction can't interleave. The bid.placed and bid.outbid events are
+// written to the outbox with the bid.
+//
+// The highest bid it beat is returned too when it was another bidder's, so
+// they can be told they were outbid.
+func (bs *BidsService) PlaceBid(ctx context.Context, productID, bidderID uuid.UUID, amount float64) (pg.Bid, *pg.Bid, error) {
+	tx, err := bs.pool.Begin(ctx)
+	if err != nil {
+		return pg.Bid{}, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := bs.queries.WithTx(tx)
+	product, err := qtx.GetProductByIDForUpdate(ctx, productID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return pg.Bid{}, err
+			return pg.Bid{}, nil, ErrProductNotFound
 		}
+
+		return pg.Bid{}, nil, err
 	}
 
-	highestBid, err := bs.queries.GetHighestBidByProductID(ctx, productID)
+	if !product.AuctionEnd.After(time.Now()) {
+		return pg.Bid{}, nil, ErrAuctionEnded
+	}
+
+	highestBid, err := qtx.GetHighestBidByProductID(ctx, productID)
 	if err != nil {
 		if !errors.Is(err, pgx.ErrNoRows) {
-			return pg.Bid{}, err
+			return pg.Bid{}, nil, err
 		}
 	}
 
 	if product.BasePrice >= amount || highestBid.Amount >= amount {
-		return pg.Bid{}, ErrBidAmountTooLow
+		return pg.Bid{}, nil, ErrBidAmountTooLow
 	}
 
-	highestBid, err = bs.queries.CreateBid(ctx, pg.CreateBidParams{
+	bid, err := qtx.CreateBid(ctx, pg.CreateBidParams{
 		ProductID: productID,
 		BidderID:  bidderID,
 		Amount:    amount,
 	})
 	if err != nil {
-		return pg.Bid{}, err
+		return pg.Bid{}, nil, err
+	}
+
+	err = enqueueOutboxEvent(ctx, qtx, TopicBidPlaced, productID, BidPlacedEvent{
+		BidID:     bid.ID,
+		ProductID: productID,
+		SellerID:  product.SellerID,
+		BidderID:  bidderID,
+		Amount:    bid.Amount,
+		PlacedAt:  bid.CreatedAt,
+	})
+	if err != nil {
+		return pg.Bid{}, nil, err
+	}
+
+	var outbid *pg.Bid
+	if highestBid.ID != uuid.Nil && highestBid.BidderID != bidderID {
+		outbid = &highestBid
+		err = enqueueOutboxEvent(ctx, qtx, TopicBidOutbid, productID, BidOutbidEvent{
+			ProductID:      productID,
+			UserID:         highestBid.BidderID,
+			PreviousBidID:  highestBid.ID,
+			PreviousAmount: highestBid.Amount,
+			BidID:          bid.ID,
+			Amount:         bid.Amount,
+		})
+		if err != nil {
+			return pg.Bid{}, nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return pg.Bid{}, nil, err
 	}
 
-	return highestBid, nil
+	return bid, outbid, nil
 }
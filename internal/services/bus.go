@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// Kinds of BusEvent.
+const (
+	BusEventRoom         = "room"
+	BusEventUser         = "user"
+	BusEventEvict        = "evict"
+	BusEventNotification = "notification"
+	BusEventPresence     = "presence"
+)
+
+// BusEvent is relayed between the instances serving the same auctions.
+// Room events carry a room wide Message, or only its Seq when it is too large
+// for a notification, user events a Message for the connections of its
+// UserID, evictions the user to disconnect, notifications a new Notification
+// for the NotificationHub, and presence events the Presence of the room on
+// the instance.
+type BusEvent struct {
+	Origin       uuid.UUID     `json:"origin"`
+	Kind         string        `json:"kind"`
+	ProductID    uuid.UUID     `json:"product_id,omitempty"`
+	Seq          uint64        `json:"seq,omitempty"`
+	Message      *Message      `json:"message,omitempty"`
+	Eviction     *Eviction     `json:"eviction,omitempty"`
+	Notification *Notification `json:"notification,omitempty"`
+	Presence     *Presence     `json:"presence,omitempty"`
+}
+
+// AuctionBus connects the auction rooms of every instance through Postgres
+// LISTEN/NOTIFY, and elects for each auction the instance that handles its
+// deadline, through leases renewed in auction_leases.
+type AuctionBus struct {
+	pool       *pgxpool.Pool
+	queries    *pg.Queries
+	InstanceID uuid.UUID
+}
+
+func NewAuctionBus(pool *pgxpool.Pool) *AuctionBus {
+	return &AuctionBus{
+		pool:       pool,
+		queries:    pg.New(pool),
+		InstanceID: uuid.New(),
+	}
+}
+
+// Publish sends event to the other instances. Events sent by an instance are
+// ignored by its own listener.
+func (b *AuctionBus) Publish(ctx context.Context, event BusEvent) error {
+	event.Origin = b.InstanceID
+	payload, err := busPayload(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.pool.Exec(ctx, "SELECT pg_notify($1, $2)", AuctionBusChannel, payload)
+	return err
+}
+
+// busPayload encodes event for a notification, which must be shorter than
+// MaxBusPayloadSize. Larger room events are sent by sequence number, for the
+// listeners to read them back from the event log; other events fail with
+// ErrBusEventTooLarge.
+func busPayload(event BusEvent) (string, error) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return "", err
+	}
+
+	if len(payload) >= MaxBusPayloadSize && event.Kind == BusEventRoom && event.Message != nil && event.Message.Seq != 0 {
+		event.Seq = event.Message.Seq
+		event.Message = nil
+		if payload, err = json.Marshal(event); err != nil {
+			return "", err
+		}
+	}
+
+	if len(payload) >= MaxBusPayloadSize {
+		return "", fmt.Errorf("%w: %s event of %d bytes", ErrBusEventTooLarge, event.Kind, len(payload))
+	}
+
+	return string(payload), nil
+}
+
+// Listen hands the events published by other instances to the rooms of
+// lobby until ctx is done, reconnecting whenever the connection drops.
+func (b *AuctionBus) Listen(ctx context.Context, lobby *AuctionLobby) {
+	for {
+		err := b.listen(ctx, lobby)
+		if ctx.Err() != nil {
+			return
+		}
+
+		slog.Error("Auction bus disconnected", "Error", err)
+		select {
+		case <-time.After(AuctionBusReconnectDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *AuctionBus) listen(ctx context.Context, lobby *AuctionLobby) error {
+	conn, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The connection keeps listening, so it must not go back to the pool.
+	defer func() {
+		conn.Conn().Close(context.Background())
+		conn.Release()
+	}()
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{AuctionBusChannel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event BusEvent
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			slog.Error("Invalid auction bus event", "Error", err)
+			continue
+		}
+
+		if event.Origin == b.InstanceID {
+			continue
+		}
+
+		lobby.dispatch(event)
+	}
+}
+
+// Lead acquires or renews the lease of the auction for this instance and
+// reports whether it is the leader, that is, the instance ending the auction.
+func (b *AuctionBus) Lead(ctx context.Context, productID uuid.UUID) (bool, error) {
+	_, err := b.queries.AcquireAuctionLease(ctx, pg.AcquireAuctionLeaseParams{
+		ProductID:  productID,
+		InstanceID: b.InstanceID,
+		ExpiresAt:  time.Now().Add(AuctionLeaseTTL),
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return true, nil
+}
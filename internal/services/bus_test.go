@@ -0,0 +1,71 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestBusPayload(t *testing.T) {
+	productID := uuid.New()
+	large := strings.Repeat("x", MaxBusPayloadSize)
+
+	tests := []struct {
+		name    string
+		event   BusEvent
+		message bool
+		seq     uint64
+		err     error
+	}{
+		{
+			name:    "room event",
+			event:   BusEvent{Kind: BusEventRoom, Message: &Message{Type: NewBidPlaced, Seq: 7}},
+			message: true,
+		},
+		{
+			name:  "large room event",
+			event: BusEvent{Kind: BusEventRoom, Message: &Message{Type: NewBidPlaced, Seq: 7, Message: large}},
+			seq:   7,
+		},
+		{
+			name:  "large unnumbered room event",
+			event: BusEvent{Kind: BusEventRoom, Message: &Message{Type: Resync, Message: large}},
+			err:   ErrBusEventTooLarge,
+		},
+		{
+			name:  "large user event",
+			event: BusEvent{Kind: BusEventUser, Message: &Message{Type: Outbid, Message: large}},
+			err:   ErrBusEventTooLarge,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.event.ProductID = productID
+			payload, err := busPayload(test.event)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("error = %v, want %v", err, test.err)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if len(payload) >= MaxBusPayloadSize {
+				t.Errorf("payload of %d bytes", len(payload))
+			}
+
+			var event BusEvent
+			if err := json.Unmarshal([]byte(payload), &event); err != nil {
+				t.Fatal(err)
+			}
+
+			if (event.Message != nil) != test.message || event.Seq != test.seq || event.ProductID != productID {
+				t.Errorf("event = %+v, want message %v and seq %d", event, test.message, test.seq)
+			}
+		})
+	}
+}
@@ -0,0 +1,458 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// Client is a websocket connection subscribed to one or more auction rooms.
+// Every room it follows writes to the same Send channel, tagging messages
+// with the product they belong to.
+type Client struct {
+	Conn *websocket.Conn
+	Send chan Message
+	// Control carries the messages ending a room stream or the connection,
+	// AuctionEnded and ConnectionClosed, apart from Send so that they are
+	// never dropped when Send is full. Readers write the messages already
+	// queued on Send before them, see Pending.
+	Control chan Message
+	Lobby   *AuctionLobby
+	UserID  uuid.UUID
+	// Protocol is the negotiated websocket subprotocol, see Subprotocols.
+	Protocol string
+	// ReadOnly clients receive room events but cannot place bids, e.g. when
+	// connected with an API token lacking the bids:write scope.
+	ReadOnly bool
+	// Multiplexed clients pick their auctions with subscribe messages and
+	// stay connected when one of them ends. Other clients follow a single
+	// auction and are disconnected once it ends.
+	Multiplexed bool
+	// Feed clients have no websocket: they only receive room wide events,
+	// consumed straight from Send, e.g. by a server-sent events stream.
+	Feed bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	rooms  map[uuid.UUID]*AuctionRoom
+
+	// closeMu guards the close frame set by disconnect. It is apart from mu,
+	// which is held while waiting on a room.
+	closeMu     sync.Mutex
+	closeCode   int
+	closeReason string
+
+	// queueMu serializes the rooms queueing on Send, see AuctionRoom.deliver.
+	queueMu sync.Mutex
+}
+
+func NewClient(conn *websocket.Conn, lobby *AuctionLobby, userID uuid.UUID) *Client {
+	protocol := conn.Subprotocol()
+	if protocol == "" {
+		protocol = ProtocolV1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Client{
+		Conn:     conn,
+		Send:     make(chan Message, ClientSendBufferSize),
+		Control:  make(chan Message, ClientControlBufferSize),
+		Lobby:    lobby,
+		UserID:   userID,
+		Protocol: protocol,
+		ctx:      ctx,
+		cancel:   cancel,
+		rooms:    make(map[uuid.UUID]*AuctionRoom),
+	}
+}
+
+// NewFeedClient returns a read only client without a websocket, following
+// the room wide events of the auctions it subscribes to.
+func NewFeedClient(lobby *AuctionLobby, userID uuid.UUID) *Client {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Client{
+		Send:     make(chan Message, ClientSendBufferSize),
+		Control:  make(chan Message, ClientControlBufferSize),
+		Lobby:    lobby,
+		UserID:   userID,
+		Protocol: ProtocolV2,
+		ReadOnly: true,
+		Feed:     true,
+		ctx:      ctx,
+		cancel:   cancel,
+		rooms:    make(map[uuid.UUID]*AuctionRoom),
+	}
+}
+
+// Subscribe registers the client in room. It fails once the client follows
+// MaxSubscriptionsPerClient auctions or if the auction already ended.
+func (c *Client) Subscribe(room *AuctionRoom) error {
+	return c.SubscribeFrom(room, 0)
+}
+
+// SubscribeFrom is like Subscribe, but first replays the room events
+// numbered after resumeFrom.
+func (c *Client) SubscribeFrom(room *AuctionRoom, resumeFrom uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.rooms[room.ID]; ok {
+		return nil
+	}
+
+	if len(c.rooms) >= MaxSubscriptionsPerClient {
+		return ErrTooManySubscriptions
+	}
+
+	select {
+	case room.Register <- Subscription{Client: c, ResumeFrom: resumeFrom}:
+	case <-room.Context.Done():
+		return ErrAuctionEnded
+	}
+
+	c.rooms[room.ID] = room
+	return nil
+}
+
+func (c *Client) Unsubscribe(productID uuid.UUID) {
+	c.mu.Lock()
+	room, ok := c.rooms[productID]
+	delete(c.rooms, productID)
+	c.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	select {
+	case room.Unregister <- c:
+	case <-room.Context.Done():
+	}
+}
+
+// Close unsubscribes the client from every room and stops its event loops.
+func (c *Client) Close() {
+	c.cancel()
+	c.unsubscribeAll()
+}
+
+// disconnect closes the client on behalf of a room, e.g. when it can't keep
+// up. The websocket is closed with code and reason.
+func (c *Client) disconnect(code int, reason string) {
+	c.closeMu.Lock()
+	c.closeCode, c.closeReason = code, reason
+	c.closeMu.Unlock()
+
+	c.cancel()
+}
+
+// Pending takes the messages queued on Send so far, to be written before a
+// message from Control.
+func (c *Client) Pending() []Message {
+	pending := make([]Message, 0, len(c.Send))
+	for range len(c.Send) {
+		select {
+		case message := <-c.Send:
+			pending = append(pending, message)
+		default:
+			return pending
+		}
+	}
+
+	return pending
+}
+
+// Done is closed once the client is closed.
+func (c *Client) Done() <-chan struct{} {
+	return c.ctx.Done()
+}
+
+func (c *Client) unsubscribeAll() {
+	c.mu.Lock()
+	ids := make([]uuid.UUID, 0, len(c.rooms))
+	for id := range c.rooms {
+		ids = append(ids, id)
+	}
+	c.mu.Unlock()
+
+	for _, id := range ids {
+		c.Unsubscribe(id)
+	}
+}
+
+// room returns the subscribed room a client frame is meant for. Single
+// auction clients may omit the product ID.
+func (c *Client) room(productID uuid.UUID) (*AuctionRoom, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if productID == uuid.Nil && !c.Multiplexed {
+		for _, room := range c.rooms {
+			return room, true
+		}
+	}
+
+	room, ok := c.rooms[productID]
+	return room, ok
+}
+
+// forget drops a room whose auction ended without notifying it.
+func (c *Client) forget(productID uuid.UUID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.rooms, productID)
+}
+
+// reply queues a message generated by the client itself, e.g. the answer to a
+// malformed frame. It is dropped if the connection is already gone.
+func (c *Client) reply(message Message) {
+	select {
+	case c.Send <- message:
+	case <-c.ctx.Done():
+	}
+}
+
+func (c *Client) handleMessage(message Message) {
+	switch message.Type {
+	case Subscribe, Unsubscribe:
+		if !c.Multiplexed {
+			c.reply(Message{
+				Message:   "subscriptions are only available on the multiplexed endpoint",
+				Type:      SubscriptionFailed,
+				ProductID: message.ProductID,
+				RequestID: message.RequestID,
+			})
+			return
+		}
+
+		if message.Type == Unsubscribe {
+			c.Unsubscribe(message.ProductID)
+			c.reply(Message{
+				Type:      Unsubscribed,
+				ProductID: message.ProductID,
+				RequestID: message.RequestID,
+			})
+			return
+		}
+
+		room, err := c.Lobby.Open(c.ctx, message.ProductID)
+		if err == nil {
+			err = c.SubscribeFrom(room, message.ResumeFrom)
+		}
+
+		if err != nil {
+			reason := err.Error()
+			if !errors.Is(err, ErrAuctionEnded) && !errors.Is(err, ErrProductNotFound) && !errors.Is(err, ErrTooManySubscriptions) {
+				slog.Error("Failed to subscribe", "Product:", message.ProductID, "Error", err)
+				reason = "could not subscribe, try again later"
+			}
+
+			c.reply(Message{
+				Message:   reason,
+				Type:      SubscriptionFailed,
+				ProductID: message.ProductID,
+				RequestID: message.RequestID,
+			})
+			return
+		}
+
+		c.reply(Message{
+			Type:      Subscribed,
+			ProductID: message.ProductID,
+			RequestID: message.RequestID,
+		})
+
+	case ClockSync:
+		c.syncClock(message)
+
+	case PlaceBid:
+		if c.ReadOnly {
+			c.reply(Message{
+				Message:   "token is missing the " + ScopeBidsWrite + " scope",
+				Type:      FailedToPlaceBid,
+				UserID:    c.UserID,
+				ProductID: message.ProductID,
+				RequestID: message.RequestID,
+			})
+			return
+		}
+
+		room, ok := c.room(message.ProductID)
+		if !ok {
+			c.reply(Message{
+				Message:   "not subscribed to this auction",
+				Type:      FailedToPlaceBid,
+				UserID:    c.UserID,
+				ProductID: message.ProductID,
+				RequestID: message.RequestID,
+			})
+			return
+		}
+
+		select {
+		case room.Broadcast <- message:
+		case <-room.Context.Done():
+			c.reply(Message{
+				Message:   ErrAuctionEnded.Error(),
+				Type:      FailedToPlaceBid,
+				UserID:    c.UserID,
+				ProductID: room.ID,
+				RequestID: message.RequestID,
+			})
+		}
+
+	default:
+		c.reply(Message{
+			Message:   "unsupported message type",
+			Type:      InvalidJSON,
+			UserID:    c.UserID,
+			RequestID: message.RequestID,
+		})
+	}
+}
+
+func (c *Client) ReadEventLoop() {
+	defer func() {
+		c.Close()
+		c.Conn.Close()
+	}()
+
+	c.Conn.SetReadLimit(MaxMessageSize)
+	c.Conn.SetReadDeadline(time.Now().Add(ReadDeadline))
+	c.Conn.SetPongHandler(func(string) error {
+		c.Conn.SetReadDeadline(time.Now().Add(ReadDeadline))
+		return nil
+	})
+
+	for {
+		_, data, err := c.Conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				slog.Error("Unexpected close error", "Error", err)
+			}
+
+			return
+		}
+
+		message, err := decodeMessage(c.Protocol, data)
+		if err != nil {
+			reason := "Invalid JSON"
+			if errors.Is(err, errUnsupportedMessage) {
+				reason = "unsupported message type or protocol version"
+			}
+
+			c.reply(Message{
+				Message:   reason,
+				Type:      InvalidJSON,
+				UserID:    c.UserID,
+				RequestID: message.RequestID,
+			})
+
+			continue
+		}
+
+		message.UserID = c.UserID
+		c.handleMessage(message)
+	}
+}
+
+func (c *Client) WriteEventLoop() {
+	ticker := time.NewTicker(PingInterval)
+	defer func() {
+		ticker.Stop()
+		c.cancel()
+		c.Conn.Close()
+	}()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			c.closeMu.Lock()
+			code, reason := c.closeCode, c.closeReason
+			c.closeMu.Unlock()
+
+			if code != 0 {
+				c.Conn.WriteControl(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(code, reason),
+					time.Now().Add(WriteDeadLine),
+				)
+			}
+			return
+
+		case <-ticker.C:
+			c.Conn.SetWriteDeadline(time.Now().Add(WriteDeadLine))
+			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				slog.Error("Unexpected write error", "Error", err)
+				return
+			}
+
+		case message := <-c.Send:
+			if !c.writeMessage(message) {
+				return
+			}
+
+		case message := <-c.Control:
+			for _, pending := range c.Pending() {
+				if !c.writeMessage(pending) {
+					return
+				}
+			}
+
+			if !c.writeMessage(message) {
+				return
+			}
+		}
+	}
+}
+
+// writeMessage writes message to the websocket and reports whether the
+// connection stays open.
+func (c *Client) writeMessage(message Message) bool {
+	if message.Type == ConnectionClosed {
+		c.Conn.WriteControl(
+			websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, message.Message),
+			time.Now().Add(WriteDeadLine),
+		)
+		return false
+	}
+
+	if err := c.write(message); err != nil {
+		return false
+	}
+
+	if message.Type == AuctionEnded {
+		c.forget(message.ProductID)
+		if !c.Multiplexed {
+			c.Conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, message.Message),
+				time.Now().Add(WriteDeadLine),
+			)
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *Client) write(message Message) error {
+	payload, err := encodeMessage(c.Protocol, message)
+	if err != nil {
+		slog.Error("Failed to encode message", "Type", message.Type, "Error", err)
+		return nil
+	}
+
+	c.Conn.SetWriteDeadline(time.Now().Add(WriteDeadLine))
+	return c.Conn.WriteJSON(payload)
+}
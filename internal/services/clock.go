@@ -0,0 +1,75 @@
+package services
+
+import (
+	"time"
+)
+
+// Clock carries the server's view of time, so clients don't have to trust
+// their own clocks. Ticks and snapshots set EndsAt and Remaining from the
+// room deadline; clock sync replies echo ClientTime.
+type Clock struct {
+	ServerTime  time.Time  `json:"server_time"`
+	EndsAt      *time.Time `json:"ends_at,omitempty"`
+	RemainingMs int64      `json:"remaining_ms,omitempty"`
+	ClientTime  int64      `json:"client_time,omitempty"`
+}
+
+// clock returns the countdown of the room, or nil if the room has no
+// deadline.
+func (r *AuctionRoom) clock() *Clock {
+	deadline, ok := r.Context.Deadline()
+	if !ok {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	endsAt := deadline.UTC()
+	return &Clock{
+		ServerTime:  now,
+		EndsAt:      &endsAt,
+		RemainingMs: max(endsAt.Sub(now), 0).Milliseconds(),
+	}
+}
+
+// nextTick returns how long to wait before the next Tick: TickInterval,
+// then TickFinalInterval in the last TickFinalWindow of the auction.
+func (r *AuctionRoom) nextTick() time.Duration {
+	deadline, _ := r.Context.Deadline()
+	untilFinal := time.Until(deadline) - TickFinalWindow
+	if untilFinal <= 0 {
+		return TickFinalInterval
+	}
+
+	return min(TickInterval, untilFinal)
+}
+
+// broadcastTick sends the countdown to every client of the room.
+func (r *AuctionRoom) broadcastTick() {
+	message := Message{
+		Type:      Tick,
+		ProductID: r.ID,
+		Clock:     r.clock(),
+	}
+
+	for _, connections := range r.Clients {
+		for client := range connections {
+			r.deliver(client, message)
+		}
+	}
+}
+
+// syncClock answers a clock sync request with the server time, echoing the
+// client time so the client can account for the round trip.
+func (c *Client) syncClock(message Message) {
+	clock := Clock{ServerTime: time.Now().UTC()}
+	if message.Clock != nil {
+		clock.ClientTime = message.Clock.ClientTime
+	}
+
+	c.reply(Message{
+		Type:      ClockSync,
+		ProductID: message.ProductID,
+		RequestID: message.RequestID,
+		Clock:     &clock,
+	})
+}
@@ -6,16 +6,233 @@ import (
 )
 
 const (
-	PgErrCodeUniqueViolation = "23505"
-	MaxMessageSize           = 512
-	ReadDeadline             = 60 * time.Second
-	WriteDeadLine            = 10 * time.Second
-	PingInterval             = (ReadDeadline * 9) / 10
+	PgErrCodeUniqueViolation  = "23505"
+	MaxMessageSize            = 512
+	ReadDeadline              = 60 * time.Second
+	WriteDeadLine             = 10 * time.Second
+	PingInterval              = (ReadDeadline * 9) / 10
+	MaxSubscriptionsPerClient = 50
+	ClientSendBufferSize      = 512
+	ClientControlBufferSize   = 2 * MaxSubscriptionsPerClient
+	RoomEventLogSize          = 256
+	MaxReplayEvents           = ClientSendBufferSize / 4
+	MaxAppendEventAttempts    = 5
+	RoomEventWriteTimeout     = 5 * time.Second
+	RoomEventReadTimeout      = 5 * time.Second
+	FeedKeepAliveInterval     = 15 * time.Second
+	PresenceInterval          = 2 * time.Second
+	PresenceActiveWindow      = 5 * time.Minute
+	PresencePublishInterval   = 30 * time.Second
+	PresenceRemoteTTL         = 3 * PresencePublishInterval
+	TickInterval              = 10 * time.Second
+	TickFinalInterval         = time.Second
+	TickFinalWindow           = time.Minute
+	AuctionBusChannel         = "auction_bus"
+	AuctionBusReconnectDelay  = time.Second
+	AuctionBusPublishTimeout  = 5 * time.Second
+	MaxBusPayloadSize         = 8000
+	AuctionLeaseInterval      = 10 * time.Second
+	AuctionLeaseTTL           = 3 * AuctionLeaseInterval
+	OutboxPollInterval        = time.Second
+	OutboxBatchSize           = 100
+	OutboxClaimTimeout        = time.Minute
+	OutboxMaxAttempts         = 10
+	OutboxBaseBackoff         = 5 * time.Second
+	OutboxMaxBackoff          = time.Hour
+	SettlementInterval        = time.Minute
+	SettlementBatchSize       = 100
+	WebhookPollInterval       = time.Second
+	WebhookBatchSize          = 50
+	WebhookClaimTimeout       = time.Minute
+	WebhookTimeout            = 10 * time.Second
+	WebhookMaxAttempts        = 8
+	WebhookBaseBackoff        = 10 * time.Second
+	WebhookMaxBackoff         = time.Hour
+	WebhookDeliveryLogSize    = 50
+	EndingSoonWindow          = 15 * time.Minute
+	ReminderInterval          = time.Minute
+	ReminderBatchSize         = 100
+	NotificationPageSize      = 20
+	MaxNotificationPageSize   = 100
+	NotificationBufferSize    = 64
+	OrderPaymentWindow        = 72 * time.Hour
+	SecondChanceOfferTTL      = 48 * time.Hour
+	DeadlineInterval          = time.Minute
+	DeadlineBatchSize         = 100
+	OrderPaymentGrace         = time.Hour
+	LedgerPageSize            = 100
+	RefundRetryInterval       = time.Minute
+	RefundBatchSize           = 50
 )
 
+// DefaultFeeRate is the share of the price the platform keeps on sales no
+// fee rule matches.
+const DefaultFeeRate = 0.05
+
+// Kinds of fee rule: a share of the price, a fixed amount, a share of the
+// price up to a cap, or shares of each band of the price.
+const (
+	FeeKindPercentage = "percentage"
+	FeeKindFixed      = "fixed"
+	FeeKindCapped     = "capped"
+	FeeKindTiered     = "tiered"
+)
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// roleRanks orders the roles, from the least to the most privileged.
+var roleRanks = map[string]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+const (
+	ApiTokenPrefix     = "bid_"
+	ScopeAuctionsRead  = "auctions:read"
+	ScopeBidsWrite     = "bids:write"
+	ScopeProductsWrite = "products:write"
+)
+
+const (
+	// Failures further apart than LoginFailureWindow start the count over.
+	LoginFailureWindow    = 15 * time.Minute
+	LoginAccountThreshold = 5
+	LoginIPThreshold      = 20
+	LoginBaseLockout      = 30 * time.Second
+	LoginMaxLockout       = 15 * time.Minute
+)
+
+var ApiTokenScopes = []string{ScopeAuctionsRead, ScopeBidsWrite, ScopeProductsWrite}
+
+const (
+	NotificationOutbid       = "outbid"
+	NotificationWon          = "won"
+	NotificationEndingSoon   = "ending_soon"
+	NotificationItemSold     = "item_sold"
+	NotificationPaymentDue   = "payment_due"
+	NotificationSecondChance = "second_chance_offer"
+)
+
+// NotificationKinds are the kinds of notification users can pick channels
+// for, see NotificationPreference.
+var NotificationKinds = []string{
+	NotificationOutbid,
+	NotificationWon,
+	NotificationEndingSoon,
+	NotificationItemSold,
+	NotificationPaymentDue,
+	NotificationSecondChance,
+}
+
+const (
+	OrderAwaitingPayment = "awaiting_payment"
+	OrderPaid            = "paid"
+	OrderShipped         = "shipped"
+	OrderDelivered       = "delivered"
+	OrderCancelled       = "cancelled"
+	OrderDefaulted       = "defaulted"
+)
+
+const (
+	SecondChancePending  = "pending"
+	SecondChanceAccepted = "accepted"
+	SecondChanceDeclined = "declined"
+	SecondChanceExpired  = "expired"
+)
+
+// Kinds of ledger account. Platform accounts are shared, seller ones belong
+// to a user.
+const (
+	LedgerPlatformCash    = "platform_cash"
+	LedgerPlatformFees    = "platform_fees"
+	LedgerSellerPending   = "seller_pending"
+	LedgerSellerAvailable = "seller_available"
+)
+
+// Kinds of ledger journal, see LedgerService.
+const (
+	LedgerJournalPayment = "payment"
+	LedgerJournalRefund  = "refund"
+	LedgerJournalEarning = "earning"
+	LedgerJournalPayout  = "payout"
+)
+
+// PaymentPending is the status of payments waiting on their provider intent,
+// which then take the status of the intent, see payments.Intent. Payments
+// that succeed for an order that no longer awaits payment are
+// PaymentRefundPending until the provider refunds them.
+const (
+	PaymentPending       = "pending"
+	PaymentRefundPending = "refund_pending"
+)
+
+// Parties that move orders between statuses, see orderTransitions.
+const (
+	OrderActorBuyer  = "buyer"
+	OrderActorSeller = "seller"
+	OrderActorSystem = "system"
+)
+
+const (
+	WebhookSecretPrefix = "whsec_"
+	TopicWebhookTest    = "webhook.test"
+)
+
+// WebhookTopics are the outbox topics users can subscribe a webhook to.
+var WebhookTopics = []string{
+	TopicBidPlaced,
+	TopicBidOutbid,
+	TopicAuctionEndingSoon,
+	TopicAuctionEnded,
+	TopicItemSold,
+	TopicOrderCreated,
+	TopicOrderStatusChanged,
+	TopicSecondChanceOffered,
+	TopicSecondChanceAccepted,
+	TopicSecondChanceDeclined,
+	TopicSecondChanceExpired,
+}
+
 var (
 	ErrDuplicatedUsernameOrEmail = errors.New("username or email already exists")
 	ErrInvalidCredentials        = errors.New("invalid credentials")
 	ErrBidAmountTooLow           = errors.New("bid amount is too low")
 	ErrProductNotFound           = errors.New("product not found")
+	ErrUserNotFound              = errors.New("user not found")
+	ErrCannotSuspendSelf         = errors.New("you can't suspend your own account")
+	ErrRoleTooHigh               = errors.New("you can't suspend a user whose role is equal to or higher than yours")
+	ErrAccountSuspended          = errors.New("account suspended")
+	ErrInvalidToken              = errors.New("invalid or revoked token")
+	ErrTokenNotFound             = errors.New("token not found")
+	ErrSessionNotFound           = errors.New("session not found")
+	ErrTooManyLoginAttempts      = errors.New("too many failed login attempts")
+	ErrAuctionEnded              = errors.New("the auction has ended")
+	ErrAuctionNotEnded           = errors.New("the auction has not ended yet")
+	ErrBusEventTooLarge          = errors.New("auction bus event too large")
+	ErrTooManySubscriptions      = errors.New("too many auction subscriptions")
+	ErrWebhookNotFound           = errors.New("webhook not found")
+	ErrInvalidWebhookURL         = errors.New("webhook url must be an absolute http or https url")
+	ErrForbiddenWebhookAddress   = errors.New("webhook url must resolve to a public address")
+	ErrNotificationNotFound      = errors.New("notification not found")
+	ErrUnsupportedLocale         = errors.New("no emails are available in this locale")
+	ErrOrderNotFound             = errors.New("order not found")
+	ErrInvalidOrderTransition    = errors.New("the order can't move to this status")
+	ErrOrderNotPayable           = errors.New("the order doesn't await a payment from this user")
+	ErrPaymentInProgress         = errors.New("the order already has a payment in progress")
+	ErrPaymentNotFound           = errors.New("payment not found")
+	ErrSecondChanceUnavailable   = errors.New("the order can't get a second-chance offer")
+	ErrNoEligibleBidder          = errors.New("no bidder is left to make an offer to")
+	ErrSecondChanceNotFound      = errors.New("second-chance offer not found")
+	ErrSecondChanceClosed        = errors.New("the offer is no longer open")
+	ErrInvalidPayoutAmount       = errors.New("payout amount must be greater than 0")
+	ErrInsufficientBalance       = errors.New("the available balance is too low")
+	ErrPayoutKeyReused           = errors.New("the idempotency key was used for another payout")
+	ErrUnbalancedJournal         = errors.New("ledger journal does not balance")
+	ErrFeeRuleNotFound           = errors.New("fee rule not found")
+	ErrInvalidFeeRule            = errors.New("invalid fee rule")
 )
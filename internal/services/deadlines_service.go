@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// DeadlineService enforces the deadlines of orders and second-chance offers:
+// orders left unpaid past their payment deadline default, and offers nobody
+// answered expire. Both are claimed with SKIP LOCKED, so several instances
+// can run it at once.
+type DeadlineService struct {
+	pool    *pgxpool.Pool
+	queries *pg.Queries
+}
+
+func NewDeadlineService(pool *pgxpool.Pool) DeadlineService {
+	return DeadlineService{
+		pool:    pool,
+		queries: pg.New(pool),
+	}
+}
+
+// Run enforces deadlines until ctx is done.
+func (ds *DeadlineService) Run(ctx context.Context) {
+	ticker := time.NewTicker(DeadlineInterval)
+	defer ticker.Stop()
+
+	for {
+		ds.drain(ctx, "Failed to default overdue orders", ds.defaultOrders)
+		ds.drain(ctx, "Failed to expire second-chance offers", ds.expireOffers)
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// drain runs batch until it handles less than DeadlineBatchSize rows. Rows
+// batch fails to handle are not counted, so they can't keep it running.
+func (ds *DeadlineService) drain(ctx context.Context, message string, batch func(context.Context) (int, error)) {
+	for {
+		n, err := batch(ctx)
+		if err != nil {
+			slog.Error(message, "Error", err)
+			return
+		}
+
+		if n < DeadlineBatchSize {
+			return
+		}
+	}
+}
+
+// defaultOrders marks a batch of orders unpaid past their deadline as
+// defaulted and returns how many it defaulted. Orders with a payment started
+// less than OrderPaymentGrace ago wait for it to settle, so that the item is
+// not offered to another bidder while the buyer may still pay; payments that
+// succeed after all are refunded, see PaymentService. An order that fails to
+// default is logged and left for the next run.
+func (ds *DeadlineService) defaultOrders(ctx context.Context) (int, error) {
+	tx, err := ds.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	orders, err := ds.queries.WithTx(tx).ClaimOverdueOrders(ctx, pg.ClaimOverdueOrdersParams{
+		PaymentsSince: time.Now().Add(-OrderPaymentGrace),
+		MaxOrders:     DeadlineBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	defaulted := 0
+	for _, order := range orders {
+		if err := ds.defaultOrder(ctx, tx, order); err != nil {
+			slog.Error("Failed to default overdue order", "ID", order.ID, "Error", err)
+			continue
+		}
+
+		defaulted++
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return defaulted, nil
+}
+
+// defaultOrder defaults an order under a savepoint of tx, which is rolled
+// back alone on failure.
+func (ds *DeadlineService) defaultOrder(ctx context.Context, tx pgx.Tx, order pg.Order) error {
+	savepoint, err := tx.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer savepoint.Rollback(ctx)
+
+	_, err = transitionOrder(ctx, ds.queries.WithTx(savepoint), order, OrderActorSystem, OrderDefaulted, "")
+	if err != nil {
+		return err
+	}
+
+	return savepoint.Commit(ctx)
+}
+
+// expireOffers expires a batch of second-chance offers past their deadline
+// and returns its size.
+func (ds *DeadlineService) expireOffers(ctx context.Context) (int, error) {
+	tx, err := ds.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := ds.queries.WithTx(tx)
+	offers, err := qtx.ExpireSecondChanceOffers(ctx, DeadlineBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, offer := range offers {
+		err := enqueueOutboxEvent(ctx, qtx, TopicSecondChanceExpired, offer.ID, newSecondChanceOfferEvent(offer))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return len(offers), nil
+}
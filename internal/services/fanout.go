@@ -0,0 +1,180 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+)
+
+// SlowConsumerPolicy decides what a room does when a client's Send buffer is
+// full. The room never blocks on a client, so a stuck connection can't hold
+// up bidding for everyone else.
+type SlowConsumerPolicy string
+
+const (
+	// SlowConsumerDropOldest discards the oldest queued message to make room.
+	SlowConsumerDropOldest SlowConsumerPolicy = "drop_oldest"
+	// SlowConsumerCoalesce discards queued price updates of the same auction
+	// superseded by the new one, then falls back to dropping the oldest.
+	SlowConsumerCoalesce SlowConsumerPolicy = "coalesce"
+	// SlowConsumerDisconnect closes the connection of the slow client.
+	SlowConsumerDisconnect SlowConsumerPolicy = "disconnect"
+)
+
+func ParseSlowConsumerPolicy(value string) (SlowConsumerPolicy, error) {
+	switch policy := SlowConsumerPolicy(value); policy {
+	case "":
+		return SlowConsumerDropOldest, nil
+	case SlowConsumerDropOldest, SlowConsumerCoalesce, SlowConsumerDisconnect:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown slow consumer policy %q", value)
+	}
+}
+
+// FanOutStats counts the messages rooms could not deliver as is since the
+// server started.
+type FanOutStats struct {
+	Dropped      uint64 `json:"dropped"`
+	Coalesced    uint64 `json:"coalesced"`
+	Disconnected uint64 `json:"disconnected"`
+}
+
+var fanOutStats struct {
+	dropped      atomic.Uint64
+	coalesced    atomic.Uint64
+	disconnected atomic.Uint64
+}
+
+func FanOutMetrics() FanOutStats {
+	return FanOutStats{
+		Dropped:      fanOutStats.dropped.Load(),
+		Coalesced:    fanOutStats.coalesced.Load(),
+		Disconnected: fanOutStats.disconnected.Load(),
+	}
+}
+
+// deliver queues message on the client without blocking the room, applying
+// the room SlowConsumerPolicy when the client is not keeping up. It reports
+// whether the message was queued.
+//
+// Control messages go through their own lane, see Client.Control, so they
+// are never dropped nor coalesced to make room. Other messages are queued
+// under the client queue lock: the rooms of a multiplexed client share its
+// Send buffer, and one room reshuffling it must not interleave with another.
+func (r *AuctionRoom) deliver(client *Client, message Message) bool {
+	if isControl(message) {
+		select {
+		case client.Control <- message:
+			return true
+		default:
+			// Only a client that stopped reading fills its control lane.
+			r.disconnectSlow(client)
+			return false
+		}
+	}
+
+	client.queueMu.Lock()
+	defer client.queueMu.Unlock()
+
+	select {
+	case client.Send <- message:
+		return true
+	default:
+	}
+
+	switch r.SlowConsumerPolicy {
+	case SlowConsumerDisconnect:
+		r.disconnectSlow(client)
+		return false
+
+	case SlowConsumerCoalesce:
+		if r.coalesce(client, message) {
+			return true
+		}
+	}
+
+	select {
+	case <-client.Send:
+		fanOutStats.dropped.Add(1)
+	default:
+	}
+
+	select {
+	case client.Send <- message:
+		return true
+	default:
+		fanOutStats.dropped.Add(1)
+		return false
+	}
+}
+
+// isControl reports whether message ends the stream of a room or the whole
+// connection, see Client.Control.
+func isControl(message Message) bool {
+	return message.Type == AuctionEnded || message.Type == ConnectionClosed
+}
+
+func (r *AuctionRoom) disconnectSlow(client *Client) {
+	slog.Warn("Disconnecting slow client", "Room:", r.ID, "User:", client.UserID)
+	r.dropClient(client)
+	client.disconnect(websocket.CloseTryAgainLater, "connection too slow")
+	fanOutStats.disconnected.Add(1)
+}
+
+// coalesce replaces the queued new bid events of the room with message, which
+// carries the latest price. Other queued messages are kept in order. It must
+// be called with the client queue lock held.
+func (r *AuctionRoom) coalesce(client *Client, message Message) bool {
+	if message.Type != NewBidPlaced {
+		return false
+	}
+
+	pending := make([]Message, 0, len(client.Send))
+drain:
+	for range cap(client.Send) {
+		select {
+		case queued := <-client.Send:
+			pending = append(pending, queued)
+		default:
+			break drain
+		}
+	}
+
+	kept := pending[:0]
+	for _, queued := range pending {
+		if queued.Type == NewBidPlaced && queued.ProductID == message.ProductID {
+			continue
+		}
+
+		kept = append(kept, queued)
+	}
+
+	coalesced := len(pending) - len(kept)
+	if coalesced > 0 {
+		kept = append(kept, message)
+	}
+
+	for _, queued := range kept {
+		select {
+		case client.Send <- queued:
+		default:
+			fanOutStats.dropped.Add(1)
+		}
+	}
+
+	fanOutStats.coalesced.Add(uint64(coalesced))
+	return coalesced > 0
+}
+
+// dropClient removes a connection from the room straight away, unlike
+// Unregister which is sent by the client itself.
+func (r *AuctionRoom) dropClient(client *Client) {
+	connections := r.Clients[client.UserID]
+	delete(connections, client)
+	if len(connections) == 0 {
+		delete(r.Clients, client.UserID)
+	}
+}
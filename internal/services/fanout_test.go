@@ -0,0 +1,290 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+const testTimeout = 2 * time.Second
+
+type fakeBids struct{}
+
+func (fakeBids) PlaceBid(_ context.Context, productID, bidderID uuid.UUID, amount float64) (pg.Bid, *pg.Bid, error) {
+	return pg.Bid{ID: uuid.New(), ProductID: productID, BidderID: bidderID, Amount: amount}, nil, nil
+}
+
+// fakeEvents logs events in memory, failing to append them while appendErr
+// is set.
+type fakeEvents struct {
+	mu        sync.Mutex
+	events    []Message
+	appendErr error
+}
+
+func (f *fakeEvents) AppendEvent(_ context.Context, _ uuid.UUID, message Message) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.appendErr != nil {
+		return 0, f.appendErr
+	}
+
+	message.Seq = uint64(len(f.events) + 1)
+	f.events = append(f.events, message)
+	return message.Seq, nil
+}
+
+func (f *fakeEvents) EventsBetween(_ context.Context, _ uuid.UUID, after, before uint64) ([]Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var events []Message
+	for _, event := range f.events {
+		if event.Seq > after && event.Seq < before {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+func (f *fakeEvents) LastSeq(context.Context, uuid.UUID) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return uint64(len(f.events)), nil
+}
+
+type fakeSettler struct{}
+
+func (fakeSettler) SettleAuction(context.Context, uuid.UUID) error {
+	return nil
+}
+
+// startRoom runs a room backed by fakes until the test ends or the returned
+// function is called, which ends the auction.
+func startRoom(t *testing.T, policy SlowConsumerPolicy) (*AuctionRoom, context.CancelFunc) {
+	t.Helper()
+	return startRoomWithEvents(t, policy, &fakeEvents{})
+}
+
+// startRoomWithEvents is like startRoom, with events already logged.
+func startRoomWithEvents(t *testing.T, policy SlowConsumerPolicy, events *fakeEvents) (*AuctionRoom, context.CancelFunc) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	room := NewAuctionRoom(ctx, uuid.New(), fakeBids{}, events)
+	room.SlowConsumerPolicy = policy
+	room.SettlementService = fakeSettler{}
+	go room.Run()
+
+	t.Cleanup(func() {
+		cancel()
+		<-room.done
+	})
+
+	return room, cancel
+}
+
+// fillSend queues messages on the client until its Send buffer is full.
+func fillSend(client *Client) {
+	for len(client.Send) < cap(client.Send) {
+		client.Send <- Message{Type: PresenceUpdate}
+	}
+}
+
+// receive waits for a message of one of the given types on ch, skipping
+// others.
+func receive(t *testing.T, ch <-chan Message, messageTypes ...MessageType) Message {
+	t.Helper()
+
+	timeout := time.After(testTimeout)
+	for {
+		select {
+		case message := <-ch:
+			if slices.Contains(messageTypes, message.Type) {
+				return message
+			}
+		case <-timeout:
+			t.Fatalf("no message of type %v received", messageTypes)
+			return Message{}
+		}
+	}
+}
+
+func TestHungClientDoesNotBlockBidding(t *testing.T) {
+	policies := []SlowConsumerPolicy{
+		SlowConsumerDropOldest,
+		SlowConsumerCoalesce,
+		SlowConsumerDisconnect,
+	}
+
+	for _, policy := range policies {
+		t.Run(string(policy), func(t *testing.T) {
+			room, _ := startRoom(t, policy)
+
+			hung := NewFeedClient(nil, uuid.New())
+			fillSend(hung)
+			if err := hung.Subscribe(room); err != nil {
+				t.Fatalf("subscribe hung client: %v", err)
+			}
+
+			follower := NewFeedClient(nil, uuid.New())
+			if err := follower.Subscribe(room); err != nil {
+				t.Fatalf("subscribe follower: %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+			defer cancel()
+
+			bidderID := uuid.New()
+			bid, err := room.PlaceBid(ctx, bidderID, 10)
+			if err != nil {
+				t.Fatalf("place bid: %v", err)
+			}
+
+			if bid.Amount != 10 {
+				t.Errorf("bid amount = %v, want 10", bid.Amount)
+			}
+
+			placed := receive(t, follower.Send, NewBidPlaced)
+			if placed.UserID != bidderID || placed.Amount != 10 {
+				t.Errorf("follower got %+v, want a bid of 10 by %s", placed, bidderID)
+			}
+
+			if policy == SlowConsumerDisconnect {
+				select {
+				case <-hung.Done():
+				case <-time.After(testTimeout):
+					t.Error("hung client was not disconnected")
+				}
+			}
+		})
+	}
+}
+
+func TestAuctionEndedReachesLaggingClient(t *testing.T) {
+	policies := []SlowConsumerPolicy{
+		SlowConsumerDropOldest,
+		SlowConsumerCoalesce,
+	}
+
+	for _, policy := range policies {
+		t.Run(string(policy), func(t *testing.T) {
+			room, end := startRoom(t, policy)
+
+			lagging := NewFeedClient(nil, uuid.New())
+			if err := lagging.Subscribe(room); err != nil {
+				t.Fatalf("subscribe: %v", err)
+			}
+
+			// Wait for the snapshot, so the room is done registering the
+			// client before its buffer fills up.
+			receive(t, lagging.Send, Snapshot)
+			fillSend(lagging)
+			end()
+
+			ended := receive(t, lagging.Control, AuctionEnded)
+			if ended.ProductID != room.ID {
+				t.Errorf("ended product = %s, want %s", ended.ProductID, room.ID)
+			}
+
+			if pending := lagging.Pending(); len(pending) != cap(lagging.Send) {
+				t.Errorf("pending = %d messages, want %d", len(pending), cap(lagging.Send))
+			}
+		})
+	}
+}
+
+func TestDeliverKeepsControlMessages(t *testing.T) {
+	room := NewAuctionRoom(context.Background(), uuid.New(), nil, nil)
+	room.SlowConsumerPolicy = SlowConsumerCoalesce
+	otherRoom := uuid.New()
+
+	client := NewFeedClient(nil, uuid.New())
+	client.Send = make(chan Message, 3)
+
+	client.Send <- Message{Type: NewBidPlaced, ProductID: room.ID, Amount: 1}
+	client.Send <- Message{Type: NewBidPlaced, ProductID: otherRoom, Amount: 5}
+	client.Send <- Message{Type: PresenceUpdate, ProductID: room.ID}
+
+	tests := []struct {
+		name    string
+		message Message
+		control bool
+	}{
+		{
+			name:    "new bid coalesced",
+			message: Message{Type: NewBidPlaced, ProductID: room.ID, Amount: 2},
+		},
+		{
+			name:    "auction ended of another room",
+			message: Message{Type: AuctionEnded, ProductID: otherRoom},
+			control: true,
+		},
+		{
+			name:    "connection closed",
+			message: Message{Type: ConnectionClosed, ProductID: room.ID},
+			control: true,
+		},
+	}
+
+	for _, test := range tests {
+		if !room.deliver(client, test.message) {
+			t.Fatalf("%s: not delivered", test.name)
+		}
+
+		if test.control {
+			if got := <-client.Control; got != test.message {
+				t.Errorf("%s: control lane got %+v", test.name, got)
+			}
+		}
+	}
+
+	want := []Message{
+		{Type: NewBidPlaced, ProductID: otherRoom, Amount: 5},
+		{Type: PresenceUpdate, ProductID: room.ID},
+		{Type: NewBidPlaced, ProductID: room.ID, Amount: 2},
+	}
+
+	pending := client.Pending()
+	if len(pending) != len(want) {
+		t.Fatalf("pending = %+v, want %+v", pending, want)
+	}
+
+	for i := range want {
+		if pending[i] != want[i] {
+			t.Errorf("pending[%d] = %+v, want %+v", i, pending[i], want[i])
+		}
+	}
+}
+
+func TestDeliverDisconnectsClientWithFullControlLane(t *testing.T) {
+	room := NewAuctionRoom(context.Background(), uuid.New(), nil, nil)
+	client := NewFeedClient(nil, uuid.New())
+	room.Clients[client.UserID] = map[*Client]struct{}{client: {}}
+
+	for len(client.Control) < cap(client.Control) {
+		client.Control <- Message{Type: AuctionEnded}
+	}
+
+	if room.deliver(client, Message{Type: AuctionEnded, ProductID: room.ID}) {
+		t.Fatal("delivered to a full control lane")
+	}
+
+	select {
+	case <-client.Done():
+	default:
+		t.Error("client was not disconnected")
+	}
+
+	if _, ok := room.Clients[client.UserID]; ok {
+		t.Error("client is still in the room")
+	}
+}
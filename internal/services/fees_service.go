@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// FeeService manages the rules of the fee the platform takes on each sale.
+// A rule applies to a category, or to every category, and to a band of
+// prices; the fee of a sale comes from the best matching rule, see
+// MatchFeeRule, or DefaultFeeRate when none matches.
+type FeeService struct {
+	pool    *pgxpool.Pool
+	queries *pg.Queries
+}
+
+func NewFeeService(pool *pgxpool.Pool) FeeService {
+	return FeeService{
+		pool:    pool,
+		queries: pg.New(pool),
+	}
+}
+
+// FeeTier is a band of a tiered fee rule: the part of the price up to UpTo,
+// and above the previous tier, is charged Rate. The last tier has no UpTo.
+type FeeTier struct {
+	UpTo *float64 `json:"up_to"`
+	Rate float64  `json:"rate"`
+}
+
+type FeeRuleParams struct {
+	Category *string
+	MinPrice float64
+	MaxPrice *float64
+	Kind     string
+	Rate     float64
+	Amount   float64
+	Cap      float64
+	Tiers    []FeeTier
+	Priority int32
+}
+
+// FeeQuote is the fee taken on a sale at Price and what is left for the
+// seller. RuleID is nil when the default fee applies.
+type FeeQuote struct {
+	Price       float64    `json:"price"`
+	Fee         float64    `json:"fee"`
+	NetProceeds float64    `json:"net_proceeds"`
+	RuleID      *uuid.UUID `json:"rule_id"`
+}
+
+func (fs *FeeService) ListRules(ctx context.Context) ([]pg.FeeRule, error) {
+	rules, err := fs.queries.ListFeeRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if rules == nil {
+		rules = []pg.FeeRule{}
+	}
+
+	return rules, nil
+}
+
+func (fs *FeeService) CreateRule(ctx context.Context, params FeeRuleParams) (pg.FeeRule, error) {
+	tiers, err := marshalFeeTiers(params)
+	if err != nil {
+		return pg.FeeRule{}, err
+	}
+
+	return fs.queries.CreateFeeRule(ctx, pg.CreateFeeRuleParams{
+		Category: params.Category,
+		MinPrice: params.MinPrice,
+		MaxPrice: params.MaxPrice,
+		Kind:     params.Kind,
+		Rate:     params.Rate,
+		Amount:   params.Amount,
+		Cap:      params.Cap,
+		Tiers:    tiers,
+		Priority: params.Priority,
+	})
+}
+
+// UpdateRule replaces the rule. Orders already settled keep their fee.
+func (fs *FeeService) UpdateRule(ctx context.Context, ruleID uuid.UUID, params FeeRuleParams) (pg.FeeRule, error) {
+	tiers, err := marshalFeeTiers(params)
+	if err != nil {
+		return pg.FeeRule{}, err
+	}
+
+	rule, err := fs.queries.UpdateFeeRule(ctx, pg.UpdateFeeRuleParams{
+		Category: params.Category,
+		MinPrice: params.MinPrice,
+		MaxPrice: params.MaxPrice,
+		Kind:     params.Kind,
+		Rate:     params.Rate,
+		Amount:   params.Amount,
+		Cap:      params.Cap,
+		Tiers:    tiers,
+		Priority: params.Priority,
+		ID:       ruleID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pg.FeeRule{}, ErrFeeRuleNotFound
+		}
+
+		return pg.FeeRule{}, err
+	}
+
+	return rule, nil
+}
+
+func (fs *FeeService) DeleteRule(ctx context.Context, ruleID uuid.UUID) error {
+	deleted, err := fs.queries.DeleteFeeRule(ctx, ruleID)
+	if err != nil {
+		return err
+	}
+
+	if deleted == 0 {
+		return ErrFeeRuleNotFound
+	}
+
+	return nil
+}
+
+// Estimate quotes the fee of a sale in the category at price with the
+// current rules.
+func (fs *FeeService) Estimate(ctx context.Context, category string, price float64) (FeeQuote, error) {
+	return quoteFee(ctx, fs.queries, category, price)
+}
+
+// quoteFee quotes the fee of a sale in the category at price, with the best
+// matching rule or, when none matches, DefaultFeeRate.
+func quoteFee(ctx context.Context, queries *pg.Queries, category string, price float64) (FeeQuote, error) {
+	rule, err := queries.MatchFeeRule(ctx, pg.MatchFeeRuleParams{
+		Category: category,
+		Price:    price,
+	})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return newFeeQuote(price, price*DefaultFeeRate, nil), nil
+	case err != nil:
+		return FeeQuote{}, err
+	}
+
+	fee, err := computeFee(rule, price)
+	if err != nil {
+		return FeeQuote{}, err
+	}
+
+	return newFeeQuote(price, fee, &rule.ID), nil
+}
+
+// newFeeQuote quotes fee on a sale at price. Fees are charged in cents and
+// never exceed the price.
+func newFeeQuote(price, fee float64, ruleID *uuid.UUID) FeeQuote {
+	fee = math.Min(math.Max(math.Round(fee*100)/100, 0), price)
+
+	return FeeQuote{
+		Price:       price,
+		Fee:         fee,
+		NetProceeds: math.Round((price-fee)*100) / 100,
+		RuleID:      ruleID,
+	}
+}
+
+// computeFee returns the fee of the rule on a sale at price, before rounding.
+func computeFee(rule pg.FeeRule, price float64) (float64, error) {
+	switch rule.Kind {
+	case FeeKindPercentage:
+		return price * rule.Rate, nil
+	case FeeKindFixed:
+		return rule.Amount, nil
+	case FeeKindCapped:
+		return math.Min(price*rule.Rate, rule.Cap), nil
+	case FeeKindTiered:
+		var tiers []FeeTier
+		if err := json.Unmarshal(rule.Tiers, &tiers); err != nil {
+			return 0, err
+		}
+
+		var fee, floor float64
+		for _, tier := range tiers {
+			ceiling := price
+			if tier.UpTo != nil {
+				ceiling = math.Min(*tier.UpTo, price)
+			}
+
+			if ceiling > floor {
+				fee += (ceiling - floor) * tier.Rate
+				floor = ceiling
+			}
+
+			if floor >= price {
+				break
+			}
+		}
+
+		return fee, nil
+	default:
+		return 0, ErrInvalidFeeRule
+	}
+}
+
+// marshalFeeTiers encodes the tiers of tiered rules; other rules store none.
+func marshalFeeTiers(params FeeRuleParams) (json.RawMessage, error) {
+	tiers := params.Tiers
+	if params.Kind != FeeKindTiered || tiers == nil {
+		tiers = []FeeTier{}
+	}
+
+	return json.Marshal(tiers)
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+func TestComputeFee(t *testing.T) {
+	tiers := []byte(`[{"up_to":100,"rate":0.1},{"up_to":1000,"rate":0.05},{"up_to":null,"rate":0.02}]`)
+
+	tests := []struct {
+		name  string
+		rule  pg.FeeRule
+		price float64
+		fee   float64
+		err   error
+	}{
+		{
+			name:  "percentage",
+			rule:  pg.FeeRule{Kind: FeeKindPercentage, Rate: 0.1},
+			price: 250,
+			fee:   25,
+		},
+		{
+			name:  "fixed",
+			rule:  pg.FeeRule{Kind: FeeKindFixed, Amount: 3},
+			price: 250,
+			fee:   3,
+		},
+		{
+			name:  "capped under the cap",
+			rule:  pg.FeeRule{Kind: FeeKindCapped, Rate: 0.1, Cap: 30},
+			price: 200,
+			fee:   20,
+		},
+		{
+			name:  "capped over the cap",
+			rule:  pg.FeeRule{Kind: FeeKindCapped, Rate: 0.1, Cap: 5},
+			price: 200,
+			fee:   5,
+		},
+		{
+			name:  "tiered within the first tier",
+			rule:  pg.FeeRule{Kind: FeeKindTiered, Tiers: tiers},
+			price: 50,
+			fee:   5,
+		},
+		{
+			name:  "tiered across two tiers",
+			rule:  pg.FeeRule{Kind: FeeKindTiered, Tiers: tiers},
+			price: 250,
+			fee:   17.5,
+		},
+		{
+			name:  "tiered into the last tier",
+			rule:  pg.FeeRule{Kind: FeeKindTiered, Tiers: tiers},
+			price: 2000,
+			fee:   75,
+		},
+		{
+			name:  "tiered without tiers",
+			rule:  pg.FeeRule{Kind: FeeKindTiered, Tiers: []byte(`[]`)},
+			price: 250,
+			fee:   0,
+		},
+		{
+			name:  "unknown kind",
+			rule:  pg.FeeRule{Kind: "bogus"},
+			price: 250,
+			err:   ErrInvalidFeeRule,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fee, err := computeFee(test.rule, test.price)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("error = %v, want %v", err, test.err)
+			}
+
+			if math.Abs(fee-test.fee) > 1e-9 {
+				t.Errorf("computeFee(%v) = %v, want %v", test.price, fee, test.fee)
+			}
+		})
+	}
+}
+
+func TestNewFeeQuote(t *testing.T) {
+	tests := []struct {
+		name        string
+		price       float64
+		fee         float64
+		quotedFee   float64
+		netProceeds float64
+	}{
+		{name: "whole cents", price: 100, fee: 12.5, quotedFee: 12.5, netProceeds: 87.5},
+		{name: "rounded down", price: 99.99, fee: 4.994, quotedFee: 4.99, netProceeds: 95},
+		{name: "rounded up", price: 10, fee: 1.235, quotedFee: 1.24, netProceeds: 8.76},
+		{name: "fraction of a cent", price: 0.05, fee: 0.004, quotedFee: 0, netProceeds: 0.05},
+		{name: "negative fee", price: 100, fee: -3, quotedFee: 0, netProceeds: 100},
+		{name: "fee over the price", price: 20, fee: 25, quotedFee: 20, netProceeds: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ruleID := uuid.New()
+			quote := newFeeQuote(test.price, test.fee, &ruleID)
+
+			if quote.Price != test.price {
+				t.Errorf("price = %v, want %v", quote.Price, test.price)
+			}
+
+			if quote.Fee != test.quotedFee {
+				t.Errorf("fee = %v, want %v", quote.Fee, test.quotedFee)
+			}
+
+			if quote.NetProceeds != test.netProceeds {
+				t.Errorf("net proceeds = %v, want %v", quote.NetProceeds, test.netProceeds)
+			}
+
+			if quote.RuleID != &ruleID {
+				t.Errorf("rule = %v, want %v", quote.RuleID, &ruleID)
+			}
+		})
+	}
+}
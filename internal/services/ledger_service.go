@@ -0,0 +1,463 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// LedgerService keeps the double-entry ledger of the money going through the
+// platform. Every movement is a journal of postings that sum to zero, in
+// cents: debits are positive and credits negative. Buyer payments debit the
+// platform cash and credit the platform fees and the seller pending earnings,
+// which become available once the order is delivered and leave the platform
+// as payouts.
+type LedgerService struct {
+	pool    *pgxpool.Pool
+	queries *pg.Queries
+}
+
+func NewLedgerService(pool *pgxpool.Pool) LedgerService {
+	return LedgerService{
+		pool:    pool,
+		queries: pg.New(pool),
+	}
+}
+
+// LedgerBalance is what the platform owes a seller: earnings of orders not
+// delivered yet are pending, the others are available for payout.
+type LedgerBalance struct {
+	Pending   float64 `json:"pending"`
+	Available float64 `json:"available"`
+}
+
+type LedgerEntry struct {
+	ID          uuid.UUID `json:"id"`
+	JournalID   uuid.UUID `json:"journal_id"`
+	JournalKind string    `json:"journal_kind"`
+	ReferenceID uuid.UUID `json:"reference_id"`
+	Description string    `json:"description"`
+	Account     string    `json:"account"`
+	// Amount is positive when the seller is owed more, negative otherwise.
+	Amount    float64   `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LedgerPayout struct {
+	ID        uuid.UUID     `json:"id"`
+	Amount    float64       `json:"amount"`
+	Balance   LedgerBalance `json:"balance"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// Reconciliation is the result of checking that the ledger balances.
+type Reconciliation struct {
+	Journals   int64                                `json:"journals"`
+	Postings   int64                                `json:"postings"`
+	Total      int64                                `json:"total"`
+	Unbalanced []pg.ListUnbalancedLedgerJournalsRow `json:"unbalanced"`
+}
+
+// Balanced reports whether every journal, and so the whole ledger, sums to
+// zero.
+func (r Reconciliation) Balanced() bool {
+	return r.Total == 0 && len(r.Unbalanced) == 0
+}
+
+// Balance returns the balance of the seller.
+func (ls *LedgerService) Balance(ctx context.Context, userID uuid.UUID) (LedgerBalance, error) {
+	return ledgerBalance(ctx, ls.queries, userID)
+}
+
+// ListEntries returns the latest postings to the accounts of the seller,
+// newest first.
+func (ls *LedgerService) ListEntries(ctx context.Context, userID uuid.UUID) ([]LedgerEntry, error) {
+	postings, err := ls.queries.ListLedgerPostingsByUserID(ctx, pg.ListLedgerPostingsByUserIDParams{
+		UserID: &userID,
+		Limit:  LedgerPageSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]LedgerEntry, 0, len(postings))
+	for _, posting := range postings {
+		entries = append(entries, LedgerEntry{
+			ID:          posting.ID,
+			JournalID:   posting.JournalID,
+			JournalKind: posting.JournalKind,
+			ReferenceID: posting.ReferenceID,
+			Description: posting.Description,
+			Account:     posting.AccountKind,
+			Amount:      fromCents(-posting.Amount),
+			CreatedAt:   posting.CreatedAt,
+		})
+	}
+
+	return entries, nil
+}
+
+// RequestPayout pays amount out of the available balance of the seller. The
+// payout is identified by key, so requesting it again with the same key pays
+// nothing more and returns the payout already made.
+func (ls *LedgerService) RequestPayout(ctx context.Context, userID, key uuid.UUID, amount float64) (LedgerPayout, error) {
+	cents := toCents(amount)
+	if cents <= 0 {
+		return LedgerPayout{}, ErrInvalidPayoutAmount
+	}
+
+	tx, err := ls.pool.Begin(ctx)
+	if err != nil {
+		return LedgerPayout{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	// Upserting the account locks it until commit, so concurrent payouts of
+	// the seller can't spend the same balance twice, nor pay the same key
+	// twice.
+	qtx := ls.queries.WithTx(tx)
+	available, err := qtx.UpsertLedgerAccount(ctx, pg.UpsertLedgerAccountParams{
+		Kind:   LedgerSellerAvailable,
+		UserID: &userID,
+	})
+	if err != nil {
+		return LedgerPayout{}, err
+	}
+
+	journal, err := qtx.GetLedgerJournal(ctx, pg.GetLedgerJournalParams{
+		Kind:        LedgerJournalPayout,
+		ReferenceID: key,
+	})
+	switch {
+	case err == nil:
+		return ls.existingPayout(ctx, qtx, journal, userID, cents)
+	case !errors.Is(err, pgx.ErrNoRows):
+		return LedgerPayout{}, err
+	}
+
+	balance, err := qtx.GetLedgerAccountBalance(ctx, available.ID)
+	if err != nil {
+		return LedgerPayout{}, err
+	}
+
+	if -balance < cents {
+		return LedgerPayout{}, ErrInsufficientBalance
+	}
+
+	journal, err = postJournal(ctx, qtx, LedgerJournalPayout, key, "Payout to seller",
+		ledgerPosting{Kind: LedgerSellerAvailable, UserID: &userID, Amount: cents},
+		ledgerPosting{Kind: LedgerPlatformCash, Amount: -cents},
+	)
+	if err != nil {
+		// Another seller took the key in the meantime.
+		if errors.Is(err, pgx.ErrNoRows) {
+			return LedgerPayout{}, ErrPayoutKeyReused
+		}
+
+		return LedgerPayout{}, err
+	}
+
+	sellerBalance, err := ledgerBalance(ctx, qtx, userID)
+	if err != nil {
+		return LedgerPayout{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return LedgerPayout{}, err
+	}
+
+	return LedgerPayout{
+		ID:        key,
+		Amount:    fromCents(cents),
+		Balance:   sellerBalance,
+		CreatedAt: journal.CreatedAt,
+	}, nil
+}
+
+// existingPayout returns the payout of journal, made by an earlier request
+// with the same key.
+func (ls *LedgerService) existingPayout(
+	ctx context.Context,
+	qtx *pg.Queries,
+	journal pg.LedgerJournal,
+	userID uuid.UUID,
+	cents int64,
+) (LedgerPayout, error) {
+	postings, err := qtx.ListLedgerJournalPostings(ctx, pg.ListLedgerJournalPostingsParams{
+		Kind:        journal.Kind,
+		ReferenceID: journal.ReferenceID,
+	})
+	if err != nil {
+		return LedgerPayout{}, err
+	}
+
+	if err := samePayout(postings, userID, cents); err != nil {
+		return LedgerPayout{}, err
+	}
+
+	sellerBalance, err := ledgerBalance(ctx, qtx, userID)
+	if err != nil {
+		return LedgerPayout{}, err
+	}
+
+	return LedgerPayout{
+		ID:        journal.ReferenceID,
+		Amount:    fromCents(cents),
+		Balance:   sellerBalance,
+		CreatedAt: journal.CreatedAt,
+	}, nil
+}
+
+// samePayout checks that the postings of a payout journal pay cents to the
+// seller, failing with ErrPayoutKeyReused when its key was used for another
+// payout.
+func samePayout(postings []pg.ListLedgerJournalPostingsRow, userID uuid.UUID, cents int64) error {
+	for _, posting := range postings {
+		if posting.AccountKind != LedgerSellerAvailable {
+			continue
+		}
+
+		if posting.AccountUserID != nil && *posting.AccountUserID == userID && posting.Amount == cents {
+			return nil
+		}
+	}
+
+	return ErrPayoutKeyReused
+}
+
+// Reconcile checks that every journal of the ledger sums to zero.
+func (ls *LedgerService) Reconcile(ctx context.Context) (Reconciliation, error) {
+	totals, err := ls.queries.GetLedgerTotals(ctx)
+	if err != nil {
+		return Reconciliation{}, err
+	}
+
+	unbalanced, err := ls.queries.ListUnbalancedLedgerJournals(ctx)
+	if err != nil {
+		return Reconciliation{}, err
+	}
+
+	if unbalanced == nil {
+		unbalanced = []pg.ListUnbalancedLedgerJournalsRow{}
+	}
+
+	return Reconciliation{
+		Journals:   totals.Journals,
+		Postings:   totals.Postings,
+		Total:      totals.Total,
+		Unbalanced: unbalanced,
+	}, nil
+}
+
+func ledgerBalance(ctx context.Context, queries *pg.Queries, userID uuid.UUID) (LedgerBalance, error) {
+	rows, err := queries.ListLedgerBalancesByUserID(ctx, &userID)
+	if err != nil {
+		return LedgerBalance{}, err
+	}
+
+	// Seller accounts are owed money, so their balances are credits.
+	var balance LedgerBalance
+	for _, row := range rows {
+		switch row.Kind {
+		case LedgerSellerPending:
+			balance.Pending = fromCents(-row.Balance)
+		case LedgerSellerAvailable:
+			balance.Available = fromCents(-row.Balance)
+		}
+	}
+
+	return balance, nil
+}
+
+type ledgerPosting struct {
+	Kind   string
+	UserID *uuid.UUID
+	Amount int64
+}
+
+// postJournal writes a journal of postings for the reference. Journals are
+// written once per kind and reference, so posting one again does nothing and
+// returns pgx.ErrNoRows.
+func postJournal(
+	ctx context.Context,
+	qtx *pg.Queries,
+	kind string,
+	referenceID uuid.UUID,
+	description string,
+	postings ...ledgerPosting,
+) (pg.LedgerJournal, error) {
+	var total int64
+	for _, posting := range postings {
+		total += posting.Amount
+	}
+
+	if total != 0 {
+		return pg.LedgerJournal{}, fmt.Errorf("%w: %s journal of %s is off by %d", ErrUnbalancedJournal, kind, referenceID, total)
+	}
+
+	journal, err := qtx.CreateLedgerJournal(ctx, pg.CreateLedgerJournalParams{
+		Kind:        kind,
+		ReferenceID: referenceID,
+		Description: description,
+	})
+	if err != nil {
+		return pg.LedgerJournal{}, err
+	}
+
+	for _, posting := range postings {
+		if posting.Amount == 0 {
+			continue
+		}
+
+		account, err := qtx.UpsertLedgerAccount(ctx, pg.UpsertLedgerAccountParams{
+			Kind:   posting.Kind,
+			UserID: posting.UserID,
+		})
+		if err != nil {
+			return pg.LedgerJournal{}, err
+		}
+
+		err = qtx.CreateLedgerPosting(ctx, pg.CreateLedgerPostingParams{
+			JournalID: journal.ID,
+			AccountID: account.ID,
+			Amount:    posting.Amount,
+		})
+		if err != nil {
+			return pg.LedgerJournal{}, err
+		}
+	}
+
+	return journal, nil
+}
+
+// recordPayment records the buyer payment of an order: the platform cash
+// takes it all, keeping the fee of the order and holding the rest for the
+// seller until the order is delivered.
+func recordPayment(ctx context.Context, qtx *pg.Queries, order pg.Order, payment pg.Payment) error {
+	amount := toCents(payment.Amount)
+	fee := min(toCents(order.Fee), amount)
+
+	_, err := postJournal(ctx, qtx, LedgerJournalPayment, payment.ID, "Payment of order "+order.ID.String(),
+		ledgerPosting{Kind: LedgerPlatformCash, Amount: amount},
+		ledgerPosting{Kind: LedgerPlatformFees, Amount: -fee},
+		ledgerPosting{Kind: LedgerSellerPending, UserID: &order.SellerID, Amount: fee - amount},
+	)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	return nil
+}
+
+// recordRefund reverses the journal of a refunded payment. Earnings already
+// released to the seller are taken back from the available balance, which
+// goes negative when they were paid out.
+func recordRefund(ctx context.Context, qtx *pg.Queries, payment pg.Payment) error {
+	postings, err := qtx.ListLedgerJournalPostings(ctx, pg.ListLedgerJournalPostingsParams{
+		Kind:        LedgerJournalPayment,
+		ReferenceID: payment.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Payments made before the ledger existed have nothing to reverse.
+	if len(postings) == 0 {
+		return nil
+	}
+
+	earnings, err := qtx.ListLedgerJournalPostings(ctx, pg.ListLedgerJournalPostingsParams{
+		Kind:        LedgerJournalEarning,
+		ReferenceID: payment.OrderID,
+	})
+	if err != nil {
+		return err
+	}
+
+	reversal := refundPostings(postings, len(earnings) > 0)
+	_, err = postJournal(ctx, qtx, LedgerJournalRefund, payment.ID, "Refund of order "+payment.OrderID.String(), reversal...)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	return nil
+}
+
+// refundPostings reverses the postings of a payment journal. Once the
+// earnings are released, the seller share is reversed from the available
+// account instead of the pending one.
+func refundPostings(postings []pg.ListLedgerJournalPostingsRow, released bool) []ledgerPosting {
+	reversal := make([]ledgerPosting, 0, len(postings))
+	for _, posting := range postings {
+		kind := posting.AccountKind
+		if released && kind == LedgerSellerPending {
+			kind = LedgerSellerAvailable
+		}
+
+		reversal = append(reversal, ledgerPosting{
+			Kind:   kind,
+			UserID: posting.AccountUserID,
+			Amount: -posting.Amount,
+		})
+	}
+
+	return reversal
+}
+
+// releaseEarnings makes the earnings of a delivered order available to its
+// seller.
+func releaseEarnings(ctx context.Context, qtx *pg.Queries, order pg.Order) error {
+	payment, err := qtx.GetSucceededPaymentByOrderID(ctx, order.ID)
+	if err != nil {
+		// Orders paid before the ledger existed have nothing to release.
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+
+		return err
+	}
+
+	postings, err := qtx.ListLedgerJournalPostings(ctx, pg.ListLedgerJournalPostingsParams{
+		Kind:        LedgerJournalPayment,
+		ReferenceID: payment.ID,
+	})
+	if err != nil {
+		return err
+	}
+
+	var earnings int64
+	for _, posting := range postings {
+		if posting.AccountKind == LedgerSellerPending {
+			earnings -= posting.Amount
+		}
+	}
+
+	if earnings == 0 {
+		return nil
+	}
+
+	_, err = postJournal(ctx, qtx, LedgerJournalEarning, order.ID, "Earnings of order "+order.ID.String(),
+		ledgerPosting{Kind: LedgerSellerPending, UserID: &order.SellerID, Amount: earnings},
+		ledgerPosting{Kind: LedgerSellerAvailable, UserID: &order.SellerID, Amount: -earnings},
+	)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	return nil
+}
+
+func toCents(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromCents(cents int64) float64 {
+	return float64(cents) / 100
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+var errFakeDB = errors.New("fake database")
+
+// fakeDB fails every query, counting them.
+type fakeDB struct {
+	queries int
+}
+
+func (db *fakeDB) Exec(context.Context, string, ...any) (pgconn.CommandTag, error) {
+	db.queries++
+	return pgconn.CommandTag{}, errFakeDB
+}
+
+func (db *fakeDB) Query(context.Context, string, ...any) (pgx.Rows, error) {
+	db.queries++
+	return nil, errFakeDB
+}
+
+func (db *fakeDB) QueryRow(context.Context, string, ...any) pgx.Row {
+	db.queries++
+	return fakeRow{}
+}
+
+type fakeRow struct{}
+
+func (fakeRow) Scan(...any) error {
+	return errFakeDB
+}
+
+func TestPostJournalBalance(t *testing.T) {
+	sellerID := uuid.New()
+
+	tests := []struct {
+		name     string
+		postings []ledgerPosting
+		balanced bool
+	}{
+		{
+			name:     "no postings",
+			balanced: true,
+		},
+		{
+			name: "payment",
+			postings: []ledgerPosting{
+				{Kind: LedgerPlatformCash, Amount: 10000},
+				{Kind: LedgerPlatformFees, Amount: -500},
+				{Kind: LedgerSellerPending, UserID: &sellerID, Amount: -9500},
+			},
+			balanced: true,
+		},
+		{
+			name: "zero posting",
+			postings: []ledgerPosting{
+				{Kind: LedgerSellerPending, UserID: &sellerID, Amount: 0},
+			},
+			balanced: true,
+		},
+		{
+			name: "one sided",
+			postings: []ledgerPosting{
+				{Kind: LedgerPlatformCash, Amount: 10000},
+			},
+		},
+		{
+			name: "off by a cent",
+			postings: []ledgerPosting{
+				{Kind: LedgerPlatformCash, Amount: 10000},
+				{Kind: LedgerPlatformFees, Amount: -500},
+				{Kind: LedgerSellerPending, UserID: &sellerID, Amount: -9499},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := &fakeDB{}
+			_, err := postJournal(context.Background(), pg.New(db), LedgerJournalPayment, uuid.New(), "test", test.postings...)
+
+			if test.balanced {
+				// Balanced journals go on to be written, which fails here.
+				if !errors.Is(err, errFakeDB) {
+					t.Errorf("error = %v, want %v", err, errFakeDB)
+				}
+
+				return
+			}
+
+			if !errors.Is(err, ErrUnbalancedJournal) {
+				t.Errorf("error = %v, want %v", err, ErrUnbalancedJournal)
+			}
+
+			if db.queries != 0 {
+				t.Errorf("unbalanced journal ran %d queries, want none", db.queries)
+			}
+		})
+	}
+}
+
+func TestCents(t *testing.T) {
+	tests := []struct {
+		amount float64
+		cents  int64
+	}{
+		{amount: 0, cents: 0},
+		{amount: 0.1, cents: 10},
+		{amount: 0.29, cents: 29},
+		{amount: 19.99, cents: 1999},
+		{amount: 0.125, cents: 13},
+		{amount: -4.2, cents: -420},
+	}
+
+	for _, test := range tests {
+		t.Run(strconv.FormatFloat(test.amount, 'f', -1, 64), func(t *testing.T) {
+			if got := toCents(test.amount); got != test.cents {
+				t.Errorf("toCents(%v) = %d, want %d", test.amount, got, test.cents)
+			}
+		})
+	}
+
+	for _, cents := range []int64{0, 1, 10, 1999, -420} {
+		if got := toCents(fromCents(cents)); got != cents {
+			t.Errorf("toCents(fromCents(%d)) = %d", cents, got)
+		}
+	}
+}
+
+func TestRefundPostings(t *testing.T) {
+	sellerID := uuid.New()
+	payment := []pg.ListLedgerJournalPostingsRow{
+		{AccountKind: LedgerPlatformCash, Amount: 10000},
+		{AccountKind: LedgerPlatformFees, Amount: -500},
+		{AccountKind: LedgerSellerPending, AccountUserID: &sellerID, Amount: -9500},
+	}
+
+	tests := []struct {
+		name     string
+		released bool
+		want     []ledgerPosting
+	}{
+		{
+			name: "earnings pending",
+			want: []ledgerPosting{
+				{Kind: LedgerPlatformCash, Amount: -10000},
+				{Kind: LedgerPlatformFees, Amount: 500},
+				{Kind: LedgerSellerPending, UserID: &sellerID, Amount: 9500},
+			},
+		},
+		{
+			name:     "earnings released",
+			released: true,
+			want: []ledgerPosting{
+				{Kind: LedgerPlatformCash, Amount: -10000},
+				{Kind: LedgerPlatformFees, Amount: 500},
+				{Kind: LedgerSellerAvailable, UserID: &sellerID, Amount: 9500},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := refundPostings(payment, test.released)
+			if len(got) != len(test.want) {
+				t.Fatalf("postings = %+v, want %+v", got, test.want)
+			}
+
+			var total int64
+			for i, posting := range got {
+				want := test.want[i]
+				if posting.Kind != want.Kind || posting.UserID != want.UserID || posting.Amount != want.Amount {
+					t.Errorf("posting %d = %+v, want %+v", i, posting, want)
+				}
+				total += posting.Amount
+			}
+
+			if total != 0 {
+				t.Errorf("reversal is off by %d", total)
+			}
+		})
+	}
+}
+
+func TestSamePayout(t *testing.T) {
+	sellerID, otherID := uuid.New(), uuid.New()
+	postings := []pg.ListLedgerJournalPostingsRow{
+		{AccountKind: LedgerSellerAvailable, AccountUserID: &sellerID, Amount: 2500},
+		{AccountKind: LedgerPlatformCash, Amount: -2500},
+	}
+
+	tests := []struct {
+		name   string
+		userID uuid.UUID
+		cents  int64
+		err    error
+	}{
+		{name: "same payout", userID: sellerID, cents: 2500},
+		{name: "other amount", userID: sellerID, cents: 2000, err: ErrPayoutKeyReused},
+		{name: "other seller", userID: otherID, cents: 2500, err: ErrPayoutKeyReused},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := samePayout(postings, test.userID, test.cents); !errors.Is(err, test.err) {
+				t.Errorf("error = %v, want %v", err, test.err)
+			}
+		})
+	}
+}
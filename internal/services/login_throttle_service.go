@@ -0,0 +1,137 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// LoginThrottleService tracks failed logins per account and per IP address.
+// Once a key reaches its threshold it is locked for LoginBaseLockout, doubling
+// with every further failure up to LoginMaxLockout.
+type LoginThrottleService struct {
+	pool    *pgxpool.Pool
+	queries *pg.Queries
+}
+
+func NewLoginThrottleService(pool *pgxpool.Pool) LoginThrottleService {
+	return LoginThrottleService{
+		pool:    pool,
+		queries: pg.New(pool),
+	}
+}
+
+// Check returns ErrTooManyLoginAttempts and how long the caller has to wait if
+// either the account or the IP address is locked.
+func (ls *LoginThrottleService) Check(ctx context.Context, email, ipAddress string) (time.Duration, error) {
+	locks, err := ls.queries.ListActiveLoginLocks(ctx, []string{accountKey(email), ipKey(ipAddress)})
+	if err != nil {
+		return 0, err
+	}
+
+	var retryAfter time.Duration
+	for _, lock := range locks {
+		if lock.LockedUntil == nil {
+			continue
+		}
+
+		retryAfter = max(retryAfter, time.Until(*lock.LockedUntil))
+	}
+
+	if retryAfter > 0 {
+		return retryAfter, ErrTooManyLoginAttempts
+	}
+
+	return 0, nil
+}
+
+// RecordFailure counts a failed login against the account and the IP address,
+// locking and auditing whichever crossed its threshold.
+func (ls *LoginThrottleService) RecordFailure(ctx context.Context, email, ipAddress string) error {
+	keys := []struct {
+		key       string
+		threshold int32
+	}{
+		{accountKey(email), LoginAccountThreshold},
+		{ipKey(ipAddress), LoginIPThreshold},
+	}
+
+	for _, k := range keys {
+		failures, err := ls.queries.RecordLoginFailure(ctx, pg.RecordLoginFailureParams{
+			Key:         k.key,
+			WindowStart: time.Now().Add(-LoginFailureWindow),
+		})
+		if err != nil {
+			return err
+		}
+
+		if failures < k.threshold {
+			continue
+		}
+
+		lockedUntil := time.Now().Add(lockoutDuration(failures - k.threshold))
+		if err := ls.lock(ctx, k.key, failures, ipAddress, lockedUntil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordSuccess clears the failures of the account. The IP address keeps its
+// count so that logging into one account doesn't reset guessing on others.
+func (ls *LoginThrottleService) RecordSuccess(ctx context.Context, email string) error {
+	return ls.queries.ResetLoginThrottle(ctx, accountKey(email))
+}
+
+func (ls *LoginThrottleService) lock(ctx context.Context, key string, failures int32, ipAddress string, lockedUntil time.Time) error {
+	tx, err := ls.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := ls.queries.WithTx(tx)
+	err = qtx.LockLoginKey(ctx, pg.LockLoginKeyParams{
+		Key:         key,
+		LockedUntil: &lockedUntil,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = qtx.CreateLoginLockout(ctx, pg.CreateLoginLockoutParams{
+		Key:         key,
+		Failures:    failures,
+		IpAddress:   ipAddress,
+		LockedUntil: lockedUntil,
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+func lockoutDuration(excessFailures int32) time.Duration {
+	d := LoginBaseLockout
+	for range excessFailures {
+		d *= 2
+		if d >= LoginMaxLockout {
+			return LoginMaxLockout
+		}
+	}
+
+	return d
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+func ipKey(ipAddress string) string {
+	return "ip:" + ipAddress
+}
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// Notification is an entry of a user's inbox, as shown to them.
+type Notification struct {
+	ID        uuid.UUID       `json:"id"`
+	UserID    uuid.UUID       `json:"user_id"`
+	Kind      string          `json:"kind"`
+	Title     string          `json:"title"`
+	Body      string          `json:"body"`
+	Data      json.RawMessage `json:"data"`
+	ReadAt    *time.Time      `json:"read_at"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// EventNotification is the v2 envelope type of pushed notifications.
+const EventNotification = "notification"
+
+// NotificationHub pushes new notifications to the websockets their user has
+// open on the notification channel. Notifications created on other
+// instances come in through the auction bus, see AuctionLobby.
+type NotificationHub struct {
+	mu      sync.Mutex
+	clients map[uuid.UUID]map[*NotificationClient]struct{}
+	Bus     *AuctionBus
+}
+
+func NewNotificationHub(bus *AuctionBus) *NotificationHub {
+	return &NotificationHub{
+		clients: make(map[uuid.UUID]map[*NotificationClient]struct{}),
+		Bus:     bus,
+	}
+}
+
+func (h *NotificationHub) Register(client *NotificationClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[client.UserID]; !ok {
+		h.clients[client.UserID] = make(map[*NotificationClient]struct{})
+	}
+
+	h.clients[client.UserID][client] = struct{}{}
+}
+
+func (h *NotificationHub) Unregister(client *NotificationClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.clients[client.UserID], client)
+	if len(h.clients[client.UserID]) == 0 {
+		delete(h.clients, client.UserID)
+	}
+}
+
+// Push delivers a new notification to its user, on every instance.
+func (h *NotificationHub) Push(ctx context.Context, notification Notification) {
+	h.deliver(notification)
+
+	if h.Bus == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, AuctionBusPublishTimeout)
+	defer cancel()
+
+	event := BusEvent{Kind: BusEventNotification, Notification: &notification}
+	if err := h.Bus.Publish(ctx, event); err != nil {
+		slog.Error("Failed to publish notification", "User:", notification.UserID, "Error", err)
+	}
+}
+
+// deliver hands a notification to the clients of this instance without
+// blocking. Clients that can't keep up are disconnected; they catch up
+// through the inbox once reconnected.
+func (h *NotificationHub) deliver(notification Notification) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.clients[notification.UserID] {
+		select {
+		case client.Send <- notification:
+		default:
+			slog.Warn("Disconnecting slow notification client", "User:", client.UserID)
+			client.disconnect(websocket.ClosePolicyViolation, "client is too slow")
+		}
+	}
+}
+
+// evict disconnects the clients of a user on this instance.
+func (h *NotificationHub) evict(eviction Eviction) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for client := range h.clients[eviction.UserID] {
+		client.disconnect(websocket.ClosePolicyViolation, eviction.Reason)
+	}
+}
+
+// NotificationClient is a websocket following the notifications of its
+// user. It only receives: frames sent by the client are ignored.
+type NotificationClient struct {
+	Conn   *websocket.Conn
+	Send   chan Notification
+	Hub    *NotificationHub
+	UserID uuid.UUID
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	closeMu     sync.Mutex
+	closeCode   int
+	closeReason string
+}
+
+func NewNotificationClient(conn *websocket.Conn, hub *NotificationHub, userID uuid.UUID) *NotificationClient {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &NotificationClient{
+		Conn:   conn,
+		Send:   make(chan Notification, NotificationBufferSize),
+		Hub:    hub,
+		UserID: userID,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+func (c *NotificationClient) disconnect(code int, reason string) {
+	c.closeMu.Lock()
+	c.closeCode, c.closeReason = code, reason
+	c.closeMu.Unlock()
+
+	c.cancel()
+}
+
+func (c *NotificationClient) ReadEventLoop() {
+	defer func() {
+		c.cancel()
+		c.Hub.Unregister(c)
+		c.Conn.Close()
+	}()
+
+	c.Conn.SetReadLimit(MaxMessageSize)
+	c.Conn.SetReadDeadline(time.Now().Add(ReadDeadline))
+	c.Conn.SetPongHandler(func(string) error {
+		c.Conn.SetReadDeadline(time.Now().Add(ReadDeadline))
+		return nil
+	})
+
+	for {
+		if _, _, err := c.Conn.ReadMessage(); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
+				slog.Error("Unexpected close error", "Error", err)
+			}
+
+			return
+		}
+	}
+}
+
+func (c *NotificationClient) WriteEventLoop() {
+	ticker := time.NewTicker(PingInterval)
+	defer func() {
+		ticker.Stop()
+		c.cancel()
+		c.Conn.Close()
+	}()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			c.closeMu.Lock()
+			code, reason := c.closeCode, c.closeReason
+			c.closeMu.Unlock()
+
+			if code != 0 {
+				c.Conn.WriteControl(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(code, reason),
+					time.Now().Add(WriteDeadLine),
+				)
+			}
+			return
+
+		case <-ticker.C:
+			c.Conn.SetWriteDeadline(time.Now().Add(WriteDeadLine))
+			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				slog.Error("Unexpected write error", "Error", err)
+				return
+			}
+
+		case notification := <-c.Send:
+			payload, err := json.Marshal(notification)
+			if err != nil {
+				slog.Error("Failed to encode notification", "ID", notification.ID, "Error", err)
+				continue
+			}
+
+			c.Conn.SetWriteDeadline(time.Now().Add(WriteDeadLine))
+			err = c.Conn.WriteJSON(Envelope{
+				V:         2,
+				Type:      EventNotification,
+				Payload:   payload,
+				Timestamp: time.Now().UTC(),
+			})
+			if err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/mailer"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// NotificationService turns outbox events into notifications for the users
+// they are about, whether or not they are connected. Each notification goes
+// to the channels the user picked for its kind: the in-app inbox, pushed live
+// through the NotificationHub, and email.
+type NotificationService struct {
+	pool      *pgxpool.Pool
+	queries   *pg.Queries
+	mailer    mailer.Mailer
+	templates *mailer.Templates
+	hub       *NotificationHub
+}
+
+// NewNotificationService returns a service sending emails through mailer,
+// rendered from the templates named after each kind of notification.
+func NewNotificationService(
+	pool *pgxpool.Pool,
+	mailer mailer.Mailer,
+	templates *mailer.Templates,
+	hub *NotificationHub,
+) NotificationService {
+	return NotificationService{
+		pool:      pool,
+		queries:   pg.New(pool),
+		mailer:    mailer,
+		templates: templates,
+		hub:       hub,
+	}
+}
+
+// ListNotifications returns a page of the inbox of the user, newest first,
+// starting after the notification cursor when it is set.
+func (ns *NotificationService) ListNotifications(
+	ctx context.Context,
+	userID, cursor uuid.UUID,
+	unreadOnly bool,
+	limit int32,
+) ([]Notification, error) {
+	rows, err := ns.queries.ListNotifications(ctx, pg.ListNotificationsParams{
+		UserID:           userID,
+		UnreadOnly:       unreadOnly,
+		Cursor:           cursor,
+		MaxNotifications: limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]Notification, 0, len(rows))
+	for _, row := range rows {
+		notifications = append(notifications, toNotification(row))
+	}
+
+	return notifications, nil
+}
+
+func (ns *NotificationService) CountUnread(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return ns.queries.CountUnreadNotifications(ctx, userID)
+}
+
+func (ns *NotificationService) MarkRead(ctx context.Context, userID, notificationID uuid.UUID) error {
+	rows, err := ns.queries.MarkNotificationRead(ctx, pg.MarkNotificationReadParams{
+		ID:     notificationID,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrNotificationNotFound
+	}
+
+	return nil
+}
+
+// MarkAllRead marks every unread notification of the user as read and
+// returns how many there were.
+func (ns *NotificationService) MarkAllRead(ctx context.Context, userID uuid.UUID) (int64, error) {
+	return ns.queries.MarkAllNotificationsRead(ctx, userID)
+}
+
+func toNotification(row pg.Notification) Notification {
+	return Notification{
+		ID:        row.ID,
+		UserID:    row.UserID,
+		Kind:      row.Kind,
+		Title:     row.Title,
+		Body:      row.Body,
+		Data:      row.Data,
+		ReadAt:    row.ReadAt,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+// NotificationPreference tells which channels a kind of notification is
+// sent to. Kinds a user never set go to every channel.
+type NotificationPreference struct {
+	Kind  string `json:"kind"`
+	InApp bool   `json:"in_app"`
+	Email bool   `json:"email"`
+}
+
+// Preferences returns the preferences of the user for every kind of
+// notification.
+func (ns *NotificationService) Preferences(ctx context.Context, userID uuid.UUID) ([]NotificationPreference, error) {
+	rows, err := ns.queries.ListNotificationPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make(map[string]NotificationPreference, len(rows))
+	for _, row := range rows {
+		stored[row.Kind] = NotificationPreference{Kind: row.Kind, InApp: row.InApp, Email: row.Email}
+	}
+
+	preferences := make([]NotificationPreference, 0, len(NotificationKinds))
+	for _, kind := range NotificationKinds {
+		preference, ok := stored[kind]
+		if !ok {
+			preference = defaultNotificationPreference(kind)
+		}
+
+		preferences = append(preferences, preference)
+	}
+
+	return preferences, nil
+}
+
+// UpdatePreferences stores the given preferences, leaving the other kinds
+// untouched.
+func (ns *NotificationService) UpdatePreferences(ctx context.Context, userID uuid.UUID, preferences []NotificationPreference) error {
+	tx, err := ns.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := ns.queries.WithTx(tx)
+	for _, preference := range preferences {
+		err := qtx.UpsertNotificationPreference(ctx, pg.UpsertNotificationPreferenceParams{
+			UserID: userID,
+			Kind:   preference.Kind,
+			InApp:  preference.InApp,
+			Email:  preference.Email,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// SetLocale sets the locale the emails of the user are rendered in. An empty
+// locale goes back to the default one.
+func (ns *NotificationService) SetLocale(ctx context.Context, userID uuid.UUID, locale string) error {
+	if locale != "" && !ns.templates.HasLocale(locale) {
+		return ErrUnsupportedLocale
+	}
+
+	rows, err := ns.queries.UpdateUserLocale(ctx, pg.UpdateUserLocaleParams{
+		ID:     userID,
+		Locale: locale,
+	})
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (ns *NotificationService) preference(ctx context.Context, userID uuid.UUID, kind string) (NotificationPreference, error) {
+	row, err := ns.queries.GetNotificationPreference(ctx, pg.GetNotificationPreferenceParams{
+		UserID: userID,
+		Kind:   kind,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return defaultNotificationPreference(kind), nil
+		}
+
+		return NotificationPreference{}, err
+	}
+
+	return NotificationPreference{Kind: row.Kind, InApp: row.InApp, Email: row.Email}, nil
+}
+
+func defaultNotificationPreference(kind string) NotificationPreference {
+	return NotificationPreference{Kind: kind, InApp: true, Email: true}
+}
+
+func (ns *NotificationService) Name() string {
+	return "notifications"
+}
+
+// Deliver notifies the users an outbox event is about. Inbox entries are
+// keyed on the event ID, so an event delivered twice only shows up once;
+// emails may be sent again.
+func (ns *NotificationService) Deliver(ctx context.Context, event pg.OutboxEvent) error {
+	switch event.Topic {
+	case TopicBidOutbid:
+		var outbid BidOutbidEvent
+		if err := json.Unmarshal(event.Payload, &outbid); err != nil {
+			return err
+		}
+
+		product, err := ns.queries.GetProductByID(ctx, outbid.ProductID)
+		if err != nil {
+			return err
+		}
+
+		return ns.notify(ctx, outbid.UserID, NotificationOutbid, event,
+			"You have been outbid",
+			fmt.Sprintf("Your bid of %.2f on %s was beaten by a bid of %.2f.", outbid.PreviousAmount, product.Name, outbid.Amount),
+			map[string]any{"ProductName": product.Name, "PreviousAmount": outbid.PreviousAmount, "Amount": outbid.Amount},
+		)
+
+	case TopicAuctionEndingSoon:
+		var endingSoon AuctionEndingSoonEvent
+		if err := json.Unmarshal(event.Payload, &endingSoon); err != nil {
+			return err
+		}
+
+		product, err := ns.queries.GetProductByID(ctx, endingSoon.ProductID)
+		if err != nil {
+			return err
+		}
+
+		title := fmt.Sprintf("%s is ending soon", product.Name)
+		body := fmt.Sprintf("The auction for %s ends at %s.", product.Name, endingSoon.EndsAt.UTC().Format(time.RFC1123))
+		data := map[string]any{"ProductName": product.Name, "EndsAt": endingSoon.EndsAt}
+		for _, bidderID := range endingSoon.BidderIDs {
+			if err := ns.notify(ctx, bidderID, NotificationEndingSoon, event, title, body, data); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case TopicItemSold:
+		var sold ItemSoldEvent
+		if err := json.Unmarshal(event.Payload, &sold); err != nil {
+			return err
+		}
+
+		product, err := ns.queries.GetProductByID(ctx, sold.ProductID)
+		if err != nil {
+			return err
+		}
+
+		err = ns.notify(ctx, sold.WinnerID, NotificationWon, event,
+			fmt.Sprintf("You won %s", product.Name),
+			fmt.Sprintf("Your bid of %.2f won the auction for %s.", sold.FinalPrice, product.Name),
+			map[string]any{"ProductName": product.Name, "Amount": sold.FinalPrice},
+		)
+		if err != nil {
+			return err
+		}
+
+		return ns.notify(ctx, sold.SellerID, NotificationItemSold, event,
+			fmt.Sprintf("%s was sold", product.Name),
+			fmt.Sprintf("Your auction for %s ended with a winning bid of %.2f.", product.Name, sold.FinalPrice),
+			map[string]any{"ProductName": product.Name, "Amount": sold.FinalPrice},
+		)
+
+	case TopicOrderCreated:
+		var created OrderCreatedEvent
+		if err := json.Unmarshal(event.Payload, &created); err != nil {
+			return err
+		}
+
+		product, err := ns.queries.GetProductByID(ctx, created.ProductID)
+		if err != nil {
+			return err
+		}
+
+		dueAt := created.PaymentDueAt.UTC().Format(time.RFC1123)
+		return ns.notify(ctx, created.BuyerID, NotificationPaymentDue, event,
+			fmt.Sprintf("Payment due for %s", product.Name),
+			fmt.Sprintf("Your order for %s awaits a payment of %.2f by %s.", product.Name, created.FinalPrice, dueAt),
+			map[string]any{"ProductName": product.Name, "Amount": created.FinalPrice, "PaymentDueAt": created.PaymentDueAt},
+		)
+
+	case TopicSecondChanceOffered:
+		var offer SecondChanceOfferEvent
+		if err := json.Unmarshal(event.Payload, &offer); err != nil {
+			return err
+		}
+
+		product, err := ns.queries.GetProductByID(ctx, offer.ProductID)
+		if err != nil {
+			return err
+		}
+
+		expiresAt := offer.ExpiresAt.UTC().Format(time.RFC1123)
+		return ns.notify(ctx, offer.BidderID, NotificationSecondChance, event,
+			fmt.Sprintf("Second chance to buy %s", product.Name),
+			fmt.Sprintf("The seller of %s offers it to you at your bid of %.2f, until %s.", product.Name, offer.Amount, expiresAt),
+			map[string]any{"ProductName": product.Name, "Amount": offer.Amount, "ExpiresAt": offer.ExpiresAt},
+		)
+	}
+
+	return nil
+}
+
+// notify sends a notification about event to the channels the user picked
+// for kind. The inbox gets title and body, while emails are rendered from the
+// kind template with data.
+func (ns *NotificationService) notify(
+	ctx context.Context,
+	userID uuid.UUID,
+	kind string,
+	event pg.OutboxEvent,
+	title, body string,
+	data map[string]any,
+) error {
+	preference, err := ns.preference(ctx, userID, kind)
+	if err != nil {
+		return err
+	}
+
+	if preference.InApp {
+		payload, err := recipientPayload(event.Payload)
+		if err != nil {
+			return err
+		}
+
+		notification, err := ns.queries.CreateNotification(ctx, pg.CreateNotificationParams{
+			UserID:  userID,
+			Kind:    kind,
+			EventID: event.ID,
+			Title:   title,
+			Body:    body,
+			Data:    payload,
+		})
+		switch {
+		case err == nil:
+			if ns.hub != nil {
+				ns.hub.Push(ctx, toNotification(notification))
+			}
+		case !errors.Is(err, pgx.ErrNoRows):
+			return err
+		}
+	}
+
+	if preference.Email {
+		user, err := ns.queries.GetUserByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		email := map[string]any{"Username": user.Username}
+		maps.Copy(email, data)
+
+		message, err := ns.templates.Render(user.Locale, kind, email)
+		if err != nil {
+			return err
+		}
+
+		message.To = user.Email
+		if err := ns.mailer.Send(ctx, message); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
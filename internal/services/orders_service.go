@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// orderTransitions maps each order status to the statuses it can move to,
+// along with the parties allowed to make each move. Orders are paid and
+// refunded through the PaymentService only, and default when the
+// DeadlineService finds them unpaid past their deadline. Delivered, cancelled
+// and defaulted orders are final.
+var orderTransitions = map[string]map[string][]string{
+	OrderAwaitingPayment: {
+		OrderPaid:      {OrderActorSystem},
+		OrderCancelled: {OrderActorBuyer, OrderActorSeller, OrderActorSystem},
+		OrderDefaulted: {OrderActorSystem},
+	},
+	OrderPaid: {
+		OrderShipped:   {OrderActorSeller},
+		OrderCancelled: {OrderActorSystem},
+	},
+	OrderShipped: {
+		OrderDelivered: {OrderActorBuyer, OrderActorSystem},
+	},
+}
+
+// OrderService lets the buyer and the seller of an auction follow its order
+// from payment to delivery. Orders are created by the SettlementService.
+type OrderService struct {
+	pool    *pgxpool.Pool
+	queries *pg.Queries
+}
+
+func NewOrderService(pool *pgxpool.Pool) OrderService {
+	return OrderService{
+		pool:    pool,
+		queries: pg.New(pool),
+	}
+}
+
+// ListOrders returns the orders of the user, newest first. role picks the
+// orders they bought or sold; any other value returns both.
+func (ors *OrderService) ListOrders(ctx context.Context, userID uuid.UUID, role string) ([]pg.Order, error) {
+	orders, err := ors.queries.ListOrdersByUserID(ctx, pg.ListOrdersByUserIDParams{
+		AsBuyer:  role != OrderActorSeller,
+		UserID:   userID,
+		AsSeller: role != OrderActorBuyer,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if orders == nil {
+		orders = []pg.Order{}
+	}
+
+	return orders, nil
+}
+
+// GetOrder returns an order the user bought or sold. Orders of other users
+// are reported as not found.
+func (ors *OrderService) GetOrder(ctx context.Context, userID, orderID uuid.UUID) (pg.Order, error) {
+	order, err := ors.queries.GetOrderByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pg.Order{}, ErrOrderNotFound
+		}
+
+		return pg.Order{}, err
+	}
+
+	if orderActor(order, userID) == "" {
+		return pg.Order{}, ErrOrderNotFound
+	}
+
+	return order, nil
+}
+
+// UpdateStatus moves an order the user bought or sold to status, if
+// orderTransitions lets their side of the order do so. A tracking number may
+// be given when the order ships.
+func (ors *OrderService) UpdateStatus(
+	ctx context.Context,
+	userID, orderID uuid.UUID,
+	status, trackingNumber string,
+) (pg.Order, error) {
+	tx, err := ors.pool.Begin(ctx)
+	if err != nil {
+		return pg.Order{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := ors.queries.WithTx(tx)
+	order, err := qtx.GetOrderByIDForUpdate(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pg.Order{}, ErrOrderNotFound
+		}
+
+		return pg.Order{}, err
+	}
+
+	actor := orderActor(order, userID)
+	if actor == "" {
+		return pg.Order{}, ErrOrderNotFound
+	}
+
+	order, err = transitionOrder(ctx, qtx, order, actor, status, trackingNumber)
+	if err != nil {
+		return pg.Order{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return pg.Order{}, err
+	}
+
+	return order, nil
+}
+
+// orderActor returns the side of the order the user is on, or "" if they are
+// not part of it.
+func orderActor(order pg.Order, userID uuid.UUID) string {
+	switch userID {
+	case order.BuyerID:
+		return OrderActorBuyer
+	case order.SellerID:
+		return OrderActorSeller
+	default:
+		return ""
+	}
+}
+
+// transitionOrder moves a locked order to status on behalf of actor and
+// writes the order.status_changed event with it. The tracking number is kept
+// when none is given, and delivered orders release their earnings to the
+// seller in the ledger.
+func transitionOrder(
+	ctx context.Context,
+	qtx *pg.Queries,
+	order pg.Order,
+	actor, status, trackingNumber string,
+) (pg.Order, error) {
+	if !slices.Contains(orderTransitions[order.Status][status], actor) {
+		return pg.Order{}, ErrInvalidOrderTransition
+	}
+
+	if trackingNumber == "" {
+		trackingNumber = order.TrackingNumber
+	}
+
+	updated, err := qtx.UpdateOrderStatus(ctx, pg.UpdateOrderStatusParams{
+		Status:         status,
+		TrackingNumber: trackingNumber,
+		ID:             order.ID,
+	})
+	if err != nil {
+		return pg.Order{}, err
+	}
+
+	if status == OrderDelivered {
+		if err := releaseEarnings(ctx, qtx, updated); err != nil {
+			return pg.Order{}, err
+		}
+	}
+
+	err = enqueueOutboxEvent(ctx, qtx, TopicOrderStatusChanged, order.ID, OrderStatusChangedEvent{
+		OrderID:        order.ID,
+		ProductID:      order.ProductID,
+		SellerID:       order.SellerID,
+		BuyerID:        order.BuyerID,
+		PreviousStatus: order.Status,
+		Status:         status,
+		Actor:          actor,
+	})
+	if err != nil {
+		return pg.Order{}, err
+	}
+
+	return updated, nil
+}
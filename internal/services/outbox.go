@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// Outbox topics, named after what happened.
+const (
+	TopicBidPlaced          = "bid.placed"
+	TopicBidOutbid          = "bid.outbid"
+	TopicAuctionEnded       = "auction.ended"
+	TopicItemSold           = "item.sold"
+	TopicAuctionEndingSoon  = "auction.ending_soon"
+	TopicOrderCreated       = "order.created"
+	TopicOrderStatusChanged = "order.status_changed"
+
+	TopicSecondChanceOffered  = "second_chance.offered"
+	TopicSecondChanceAccepted = "second_chance.accepted"
+	TopicSecondChanceDeclined = "second_chance.declined"
+	TopicSecondChanceExpired  = "second_chance.expired"
+)
+
+type BidPlacedEvent struct {
+	BidID     uuid.UUID `json:"bid_id"`
+	ProductID uuid.UUID `json:"product_id"`
+	SellerID  uuid.UUID `json:"seller_id"`
+	BidderID  uuid.UUID `json:"bidder_id"`
+	Amount    float64   `json:"amount"`
+	PlacedAt  time.Time `json:"placed_at"`
+}
+
+// BidOutbidEvent tells UserID their bid was beaten by BidID.
+type BidOutbidEvent struct {
+	ProductID      uuid.UUID `json:"product_id"`
+	UserID         uuid.UUID `json:"user_id"`
+	PreviousBidID  uuid.UUID `json:"previous_bid_id"`
+	PreviousAmount float64   `json:"previous_amount"`
+	BidID          uuid.UUID `json:"bid_id"`
+	Amount         float64   `json:"amount"`
+}
+
+// AuctionEndedEvent is published for every auction. The winner is only set
+// when the item was sold.
+type AuctionEndedEvent struct {
+	ProductID  uuid.UUID  `json:"product_id"`
+	SellerID   uuid.UUID  `json:"seller_id"`
+	EndedAt    time.Time  `json:"ended_at"`
+	WinnerID   *uuid.UUID `json:"winner_id,omitempty"`
+	BidID      *uuid.UUID `json:"bid_id,omitempty"`
+	FinalPrice float64    `json:"final_price,omitempty"`
+}
+
+type ItemSoldEvent struct {
+	ProductID  uuid.UUID `json:"product_id"`
+	SellerID   uuid.UUID `json:"seller_id"`
+	WinnerID   uuid.UUID `json:"winner_id"`
+	BidID      uuid.UUID `json:"bid_id"`
+	FinalPrice float64   `json:"final_price"`
+}
+
+// AuctionEndingSoonEvent is published once per auction, EndingSoonWindow
+// before it ends, for everyone who bid on it. BidderIDs only routes the event:
+// it is stripped from what the recipients get, see recipientPayload.
+type AuctionEndingSoonEvent struct {
+	ProductID uuid.UUID   `json:"product_id"`
+	SellerID  uuid.UUID   `json:"seller_id"`
+	EndsAt    time.Time   `json:"ends_at"`
+	BidderIDs []uuid.UUID `json:"bidder_ids"`
+}
+
+// OrderCreatedEvent is published when an auction is settled with a winner, or
+// a second-chance offer is accepted, whose order then awaits payment.
+type OrderCreatedEvent struct {
+	OrderID      uuid.UUID `json:"order_id"`
+	ProductID    uuid.UUID `json:"product_id"`
+	SellerID     uuid.UUID `json:"seller_id"`
+	BuyerID      uuid.UUID `json:"buyer_id"`
+	FinalPrice   float64   `json:"final_price"`
+	PaymentDueAt time.Time `json:"payment_due_at"`
+}
+
+func newOrderCreatedEvent(order pg.Order) OrderCreatedEvent {
+	return OrderCreatedEvent{
+		OrderID:      order.ID,
+		ProductID:    order.ProductID,
+		SellerID:     order.SellerID,
+		BuyerID:      order.BuyerID,
+		FinalPrice:   order.FinalPrice,
+		PaymentDueAt: order.PaymentDueAt,
+	}
+}
+
+type OrderStatusChangedEvent struct {
+	OrderID        uuid.UUID `json:"order_id"`
+	ProductID      uuid.UUID `json:"product_id"`
+	SellerID       uuid.UUID `json:"seller_id"`
+	BuyerID        uuid.UUID `json:"buyer_id"`
+	PreviousStatus string    `json:"previous_status"`
+	Status         string    `json:"status"`
+	Actor          string    `json:"actor"`
+}
+
+// SecondChanceOfferEvent is published when a second-chance offer is made to
+// a bidder, and when it is accepted, declined or expires. OrderID is the
+// defaulted order the offer replaces.
+type SecondChanceOfferEvent struct {
+	OfferID   uuid.UUID `json:"offer_id"`
+	OrderID   uuid.UUID `json:"order_id"`
+	ProductID uuid.UUID `json:"product_id"`
+	SellerID  uuid.UUID `json:"seller_id"`
+	BidderID  uuid.UUID `json:"bidder_id"`
+	Amount    float64   `json:"amount"`
+	Status    string    `json:"status"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func newSecondChanceOfferEvent(offer pg.SecondChanceOffer) SecondChanceOfferEvent {
+	return SecondChanceOfferEvent{
+		OfferID:   offer.ID,
+		OrderID:   offer.OrderID,
+		ProductID: offer.ProductID,
+		SellerID:  offer.SellerID,
+		BidderID:  offer.BidderID,
+		Amount:    offer.Amount,
+		Status:    offer.Status,
+		ExpiresAt: offer.ExpiresAt,
+	}
+}
+
+// recipientPayload returns the payload of an event as sent to the users it is
+// about, without the bidder_ids routing it to them, so recipients don't learn
+// who else bid.
+func recipientPayload(payload []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, err
+	}
+
+	if _, ok := fields["bidder_ids"]; !ok {
+		return payload, nil
+	}
+
+	delete(fields, "bidder_ids")
+	return json.Marshal(fields)
+}
+
+// enqueueOutboxEvent writes an event to the outbox. Pass queries bound to the
+// transaction of the change the event is about, so that both are committed
+// together.
+func enqueueOutboxEvent(ctx context.Context, queries *pg.Queries, topic string, aggregateID uuid.UUID, event any) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	return queries.CreateOutboxEvent(ctx, pg.CreateOutboxEventParams{
+		Topic:       topic,
+		AggregateID: aggregateID,
+		Payload:     payload,
+	})
+}
+
+// OutboxSink receives the events of the outbox. Delivery is at least once:
+// an event is retried until every sink accepted it, so sinks must tolerate
+// duplicates, e.g. by keying on the event ID.
+type OutboxSink interface {
+	Name() string
+	Deliver(ctx context.Context, event pg.OutboxEvent) error
+}
+
+// LogSink logs every event.
+type LogSink struct{}
+
+func (LogSink) Name() string {
+	return "log"
+}
+
+func (LogSink) Deliver(_ context.Context, event pg.OutboxEvent) error {
+	slog.Info("Outbox event", "ID", event.ID, "Topic", event.Topic, "Payload", string(event.Payload))
+	return nil
+}
+
+// OutboxDispatcher delivers the outbox to its sinks. Several dispatchers,
+// e.g. one per instance, can run at once: events are claimed for
+// OutboxClaimTimeout, after which an event whose dispatcher crashed is
+// claimed again.
+type OutboxDispatcher struct {
+	pool    *pgxpool.Pool
+	queries *pg.Queries
+	sinks   []OutboxSink
+}
+
+func NewOutboxDispatcher(pool *pgxpool.Pool, sinks ...OutboxSink) *OutboxDispatcher {
+	return &OutboxDispatcher{
+		pool:    pool,
+		queries: pg.New(pool),
+		sinks:   sinks,
+	}
+}
+
+// Run dispatches the outbox until ctx is done.
+func (d *OutboxDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(OutboxPollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Keep going while there is a backlog.
+		for {
+			dispatched, err := d.dispatch(ctx)
+			if err != nil {
+				slog.Error("Failed to dispatch outbox", "Error", err)
+				break
+			}
+
+			if dispatched < OutboxBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatch delivers a batch of events and returns its size.
+func (d *OutboxDispatcher) dispatch(ctx context.Context) (int, error) {
+	events, err := d.queries.ClaimOutboxEvents(ctx, pg.ClaimOutboxEventsParams{
+		LeaseUntil: time.Now().Add(OutboxClaimTimeout),
+		MaxEvents:  OutboxBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		if err := d.deliver(ctx, event); err != nil {
+			attempts := event.Attempts + 1
+			giveUp := attempts >= OutboxMaxAttempts
+			slog.Warn("Failed to deliver outbox event", "ID", event.ID, "Topic", event.Topic, "Attempts", attempts, "GiveUp", giveUp, "Error", err)
+
+			err = d.queries.MarkOutboxEventFailed(ctx, pg.MarkOutboxEventFailedParams{
+				ID:        event.ID,
+				LastError: err.Error(),
+				RetryAt:   time.Now().Add(backoff(attempts, OutboxBaseBackoff, OutboxMaxBackoff)),
+				GiveUp:    giveUp,
+			})
+			if err != nil {
+				return 0, err
+			}
+
+			continue
+		}
+
+		if err := d.queries.MarkOutboxEventDispatched(ctx, event.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(events), nil
+}
+
+func (d *OutboxDispatcher) deliver(ctx context.Context, event pg.OutboxEvent) error {
+	var errs []error
+	for _, sink := range d.sinks {
+		if err := sink.Deliver(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// backoff doubles the wait after each failed attempt, from base up to max.
+func backoff(attempts int32, base, max time.Duration) time.Duration {
+	wait := base
+	for range attempts - 1 {
+		wait *= 2
+		if wait >= max {
+			return max
+		}
+	}
+
+	return wait
+}
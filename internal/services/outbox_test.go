@@ -0,0 +1,75 @@
+package services
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestRecipientPayloadStripsBidders(t *testing.T) {
+	bidders := []uuid.UUID{uuid.New(), uuid.New()}
+	endingSoon, err := json.Marshal(AuctionEndingSoonEvent{
+		ProductID: uuid.New(),
+		SellerID:  uuid.New(),
+		EndsAt:    time.Now(),
+		BidderIDs: bidders,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recipients, err := eventRecipients(endingSoon)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, bidderID := range bidders {
+		if !slices.Contains(recipients, bidderID) {
+			t.Errorf("bidder %s is not a recipient", bidderID)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{name: "auction ending soon", payload: endingSoon},
+		{name: "bid placed", payload: []byte(`{"bid_id":"1","bidder_id":"2"}`)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := recipientPayload(test.payload)
+			if err != nil {
+				t.Fatalf("recipientPayload: %v", err)
+			}
+
+			var got, want map[string]json.RawMessage
+			if err := json.Unmarshal(payload, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := json.Unmarshal(test.payload, &want); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, ok := got["bidder_ids"]; ok {
+				t.Error("bidder_ids were kept")
+			}
+
+			delete(want, "bidder_ids")
+			if len(got) != len(want) {
+				t.Errorf("got fields %v, want %v", got, want)
+			}
+
+			for key, value := range want {
+				if string(got[key]) != string(value) {
+					t.Errorf("%s = %s, want %s", key, got[key], value)
+				}
+			}
+		})
+	}
+}
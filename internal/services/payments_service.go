@@ -0,0 +1,394 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/payments"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// paymentTransitions maps each payment status to the statuses it can move
+// to. Provider responses and webhooks may report the same change twice or
+// out of order; changes that don't fit are ignored.
+var paymentTransitions = map[string][]string{
+	PaymentPending: {
+		payments.StatusRequiresAction,
+		payments.StatusRequiresCapture,
+		payments.StatusSucceeded,
+		payments.StatusFailed,
+	},
+	payments.StatusRequiresAction:  {payments.StatusRequiresCapture, payments.StatusSucceeded, payments.StatusFailed},
+	payments.StatusRequiresCapture: {payments.StatusSucceeded, payments.StatusFailed},
+	payments.StatusSucceeded:       {payments.StatusRefunded},
+	PaymentRefundPending:           {payments.StatusRefunded},
+}
+
+// PaymentService collects the payments of orders through a payments.Provider.
+// A payment follows the status of its provider intent, as reported both by
+// the provider responses and its webhooks; whichever comes first moves the
+// order along.
+type PaymentService struct {
+	pool     *pgxpool.Pool
+	queries  *pg.Queries
+	provider payments.Provider
+}
+
+func NewPaymentService(pool *pgxpool.Pool, provider payments.Provider) PaymentService {
+	return PaymentService{
+		pool:     pool,
+		queries:  pg.New(pool),
+		provider: provider,
+	}
+}
+
+// Pay starts paying an order of the buyer with paymentMethod. Authorized
+// payments are captured right away, so the payment comes back succeeded,
+// failed, or requiring the buyer to pass the 3-D Secure challenge at its next
+// action URL.
+func (ps *PaymentService) Pay(ctx context.Context, userID, orderID uuid.UUID, paymentMethod string) (pg.Payment, error) {
+	order, err := ps.queries.GetOrderByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pg.Payment{}, ErrOrderNotFound
+		}
+
+		return pg.Payment{}, err
+	}
+
+	switch actor := orderActor(order, userID); {
+	case actor == "":
+		return pg.Payment{}, ErrOrderNotFound
+	case actor != OrderActorBuyer || order.Status != OrderAwaitingPayment:
+		return pg.Payment{}, ErrOrderNotPayable
+	}
+
+	payment, err := ps.queries.CreatePayment(ctx, pg.CreatePaymentParams{
+		OrderID:  order.ID,
+		Provider: ps.provider.Name(),
+		Amount:   order.FinalPrice,
+	})
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == PgErrCodeUniqueViolation {
+			return pg.Payment{}, ErrPaymentInProgress
+		}
+
+		return pg.Payment{}, err
+	}
+
+	intent, err := ps.provider.CreateIntent(ctx, payments.IntentParams{
+		Reference:     payment.ID.String(),
+		Amount:        payment.Amount,
+		PaymentMethod: paymentMethod,
+	})
+	if err != nil {
+		// Failing the payment lets the buyer try again.
+		failed := payments.Intent{Status: payments.StatusFailed, FailureReason: "payment provider unavailable"}
+		if _, err := ps.update(ctx, payment.ID, failed, nil); err != nil {
+			slog.Error("Failed to record payment", "ID", payment.ID, "Error", err)
+		}
+
+		return pg.Payment{}, err
+	}
+
+	// The intent is recorded before capturing it, so that its webhooks can
+	// find the payment.
+	payment, err = ps.update(ctx, payment.ID, intent, nil)
+	if err != nil || intent.Status != payments.StatusRequiresCapture {
+		return payment, err
+	}
+
+	intent, err = ps.capture(ctx, intent)
+	if err != nil {
+		return pg.Payment{}, err
+	}
+
+	return ps.update(ctx, payment.ID, intent, nil)
+}
+
+// Refund gives the payment of a paid order back to the buyer, which cancels
+// the order. Only the seller can refund an order, before it ships.
+func (ps *PaymentService) Refund(ctx context.Context, userID, orderID uuid.UUID) (pg.Payment, error) {
+	order, err := ps.queries.GetOrderByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pg.Payment{}, ErrOrderNotFound
+		}
+
+		return pg.Payment{}, err
+	}
+
+	switch actor := orderActor(order, userID); {
+	case actor == "":
+		return pg.Payment{}, ErrOrderNotFound
+	case actor != OrderActorSeller || order.Status != OrderPaid:
+		return pg.Payment{}, ErrInvalidOrderTransition
+	}
+
+	payment, err := ps.queries.GetSucceededPaymentByOrderID(ctx, order.ID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pg.Payment{}, ErrPaymentNotFound
+		}
+
+		return pg.Payment{}, err
+	}
+
+	intent, err := ps.provider.Refund(ctx, payment.IntentID)
+	if err != nil {
+		return pg.Payment{}, err
+	}
+
+	return ps.update(ctx, payment.ID, intent, nil)
+}
+
+// ListPayments returns the payments of an order the user bought or sold,
+// newest first.
+func (ps *PaymentService) ListPayments(ctx context.Context, userID, orderID uuid.UUID) ([]pg.Payment, error) {
+	order, err := ps.queries.GetOrderByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrOrderNotFound
+		}
+
+		return nil, err
+	}
+
+	if orderActor(order, userID) == "" {
+		return nil, ErrOrderNotFound
+	}
+
+	list, err := ps.queries.ListPaymentsByOrderID(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if list == nil {
+		list = []pg.Payment{}
+	}
+
+	return list, nil
+}
+
+// HandleWebhook applies a webhook event of the provider to its payment.
+// Events are recorded along with the change they make, so an event sent
+// twice is only applied once. Intents authorized after a 3-D Secure
+// challenge are captured here.
+func (ps *PaymentService) HandleWebhook(ctx context.Context, header http.Header, body []byte) error {
+	event, err := ps.provider.VerifyWebhook(header, body)
+	if err != nil {
+		return err
+	}
+
+	payment, err := ps.queries.GetPaymentByIntentID(ctx, pg.GetPaymentByIntentIDParams{
+		Provider: ps.provider.Name(),
+		IntentID: event.Intent.ID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrPaymentNotFound
+		}
+
+		return err
+	}
+
+	intent := event.Intent
+	if event.Type == payments.EventIntentRequiresCapture {
+		if intent, err = ps.capture(ctx, intent); err != nil {
+			return err
+		}
+	}
+
+	_, err = ps.update(ctx, payment.ID, intent, &pg.RecordPaymentEventParams{
+		Provider: ps.provider.Name(),
+		EventID:  event.ID,
+		Type:     event.Type,
+		Payload:  body,
+	})
+	return err
+}
+
+// capture captures an authorized intent. An intent that can't be captured
+// anymore was captured, or failed, in the meantime; it is returned as is and
+// the change is left to its webhook.
+func (ps *PaymentService) capture(ctx context.Context, intent payments.Intent) (payments.Intent, error) {
+	captured, err := ps.provider.Capture(ctx, intent.ID)
+	if err != nil {
+		if errors.Is(err, payments.ErrInvalidIntentState) {
+			return intent, nil
+		}
+
+		return payments.Intent{}, err
+	}
+
+	return captured, nil
+}
+
+// update moves a payment to the status of its intent, recording event with
+// it when given, and moves its order along: succeeded payments mark it paid
+// and refunded ones cancel it, both being recorded in the ledger. Payments
+// that succeed for an order that no longer awaits payment, e.g. because the
+// buyer cancelled it meanwhile, are marked PaymentRefundPending in the same
+// transaction and refunded right away; refunds that fail are retried by Run.
+func (ps *PaymentService) update(
+	ctx context.Context,
+	paymentID uuid.UUID,
+	intent payments.Intent,
+	event *pg.RecordPaymentEventParams,
+) (pg.Payment, error) {
+	tx, err := ps.pool.Begin(ctx)
+	if err != nil {
+		return pg.Payment{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := ps.queries.WithTx(tx)
+	payment, err := qtx.GetPaymentByIDForUpdate(ctx, paymentID)
+	if err != nil {
+		return pg.Payment{}, err
+	}
+
+	if event != nil {
+		recorded, err := qtx.RecordPaymentEvent(ctx, *event)
+		if err != nil {
+			return pg.Payment{}, err
+		}
+
+		if recorded == 0 {
+			return payment, nil
+		}
+	}
+
+	if !slices.Contains(paymentTransitions[payment.Status], intent.Status) {
+		return payment, tx.Commit(ctx)
+	}
+
+	var order pg.Order
+	status := intent.Status
+	if status == payments.StatusSucceeded {
+		order, err = qtx.GetOrderByIDForUpdate(ctx, payment.OrderID)
+		if err != nil {
+			return pg.Payment{}, err
+		}
+
+		if order.Status != OrderAwaitingPayment {
+			status = PaymentRefundPending
+		}
+	}
+
+	payment, err = qtx.UpdatePayment(ctx, pg.UpdatePaymentParams{
+		IntentID:      intent.ID,
+		Status:        status,
+		NextActionUrl: intent.NextActionURL,
+		FailureReason: intent.FailureReason,
+		ID:            payment.ID,
+	})
+	if err != nil {
+		return pg.Payment{}, err
+	}
+
+	switch payment.Status {
+	case payments.StatusSucceeded:
+		if err := recordPayment(ctx, qtx, order, payment); err != nil {
+			return pg.Payment{}, err
+		}
+
+		if _, err := transitionOrder(ctx, qtx, order, OrderActorSystem, OrderPaid, ""); err != nil {
+			return pg.Payment{}, err
+		}
+
+	case PaymentRefundPending:
+		// The money was taken all the same, and the refund reverses it.
+		if err := recordPayment(ctx, qtx, order, payment); err != nil {
+			return pg.Payment{}, err
+		}
+
+	case payments.StatusRefunded:
+		order, err := qtx.GetOrderByIDForUpdate(ctx, payment.OrderID)
+		if err != nil {
+			return pg.Payment{}, err
+		}
+
+		if err := recordRefund(ctx, qtx, payment); err != nil {
+			return pg.Payment{}, err
+		}
+
+		if order.Status == OrderPaid {
+			if _, err := transitionOrder(ctx, qtx, order, OrderActorSystem, OrderCancelled, ""); err != nil {
+				return pg.Payment{}, err
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return pg.Payment{}, err
+	}
+
+	if payment.Status == PaymentRefundPending {
+		slog.Warn("Refunding payment of an order not awaiting payment", "ID", payment.ID, "Order", payment.OrderID)
+
+		refunded, err := ps.refund(ctx, payment)
+		if err != nil {
+			slog.Error("Failed to refund payment, retrying later", "ID", payment.ID, "Error", err)
+			return payment, nil
+		}
+
+		return refunded, nil
+	}
+
+	return payment, nil
+}
+
+// Run retries, until ctx is done, the refunds of payments left
+// PaymentRefundPending.
+func (ps *PaymentService) Run(ctx context.Context) {
+	ticker := time.NewTicker(RefundRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		pending, err := ps.queries.ListRefundPendingPayments(ctx, pg.ListRefundPendingPaymentsParams{
+			Provider: ps.provider.Name(),
+			Limit:    RefundBatchSize,
+		})
+		if err != nil {
+			slog.Error("Failed to list payments to refund", "Error", err)
+		}
+
+		for _, payment := range pending {
+			if _, err := ps.refund(ctx, payment); err != nil {
+				slog.Error("Failed to refund payment", "ID", payment.ID, "Error", err)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// refund asks the provider to refund a PaymentRefundPending payment. An
+// intent that can't be refunded anymore was refunded meanwhile, which its
+// webhook records.
+func (ps *PaymentService) refund(ctx context.Context, payment pg.Payment) (pg.Payment, error) {
+	intent, err := ps.provider.Refund(ctx, payment.IntentID)
+	if err != nil {
+		if errors.Is(err, payments.ErrInvalidIntentState) {
+			return payment, nil
+		}
+
+		return pg.Payment{}, err
+	}
+
+	return ps.update(ctx, payment.ID, intent, nil)
+}
@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Presence tells how busy an auction is without saying who is in it.
+// Watchers counts the users following the room on every instance, whatever
+// their number of connections; a user connected to several instances counts
+// once on each. ActiveBidders counts those who placed a bid in the last
+// PresenceActiveWindow.
+type Presence struct {
+	Watchers      int `json:"watchers"`
+	ActiveBidders int `json:"active_bidders"`
+}
+
+// RemoteWatchers is the number of users following a room on another
+// instance, published over the AuctionBus.
+type RemoteWatchers struct {
+	Instance uuid.UUID
+	Count    int
+}
+
+type remoteCount struct {
+	count int
+	at    time.Time
+}
+
+// currentPresence counts the room presence, forgetting the bidders that went
+// quiet and the instances not heard of in PresenceRemoteTTL.
+func (r *AuctionRoom) currentPresence() Presence {
+	since := time.Now().Add(-PresenceActiveWindow)
+	for userID, lastBidAt := range r.bidders {
+		if lastBidAt.Before(since) {
+			delete(r.bidders, userID)
+		}
+	}
+
+	watchers := len(r.Clients)
+	since = time.Now().Add(-PresenceRemoteTTL)
+	for instance, remote := range r.remoteWatchers {
+		if remote.at.Before(since) {
+			delete(r.remoteWatchers, instance)
+			continue
+		}
+
+		watchers += remote.count
+	}
+
+	return Presence{
+		Watchers:      watchers,
+		ActiveBidders: len(r.bidders),
+	}
+}
+
+// trackRemoteWatchers records the watchers of the room on another instance.
+func (r *AuctionRoom) trackRemoteWatchers(remote RemoteWatchers) {
+	r.remoteWatchers[remote.Instance] = remoteCount{count: remote.Count, at: time.Now()}
+}
+
+// publishWatchers tells the other instances how many users follow the room
+// on this one, when that changed or every PresencePublishInterval, so that
+// they know the instance is still up.
+func (r *AuctionRoom) publishWatchers() {
+	watchers := len(r.Clients)
+	if r.Bus == nil || watchers == r.watchersPublished && time.Since(r.watchersPublishedAt) < PresencePublishInterval {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context), AuctionBusPublishTimeout)
+	defer cancel()
+
+	event := BusEvent{Kind: BusEventPresence, ProductID: r.ID, Presence: &Presence{Watchers: watchers}}
+	if err := r.Bus.Publish(ctx, event); err != nil {
+		slog.Error("Failed to publish presence", "Room:", r.ID, "Error", err)
+		return
+	}
+
+	r.watchersPublished = watchers
+	r.watchersPublishedAt = time.Now()
+}
+
+// trackBidder records that userID just placed a bid.
+func (r *AuctionRoom) trackBidder(userID uuid.UUID) {
+	r.bidders[userID] = time.Now()
+}
+
+// sendSnapshot tells a client that just joined the current state of the
+// room.
+func (r *AuctionRoom) sendSnapshot(client *Client) {
+	presence := r.currentPresence()
+	r.deliver(client, Message{
+		Type:      Snapshot,
+		ProductID: r.ID,
+		Presence:  &presence,
+		Clock:     r.clock(),
+	})
+}
+
+// broadcastPresence sends a PresenceUpdate to every client when the presence
+// changed since the last one. It runs every PresenceInterval rather than on
+// each register and unregister, so a burst of joins results in one update.
+func (r *AuctionRoom) broadcastPresence() {
+	r.publishWatchers()
+
+	presence := r.currentPresence()
+	if presence == r.presence {
+		return
+	}
+
+	r.presence = presence
+	message := Message{
+		Type:      PresenceUpdate,
+		ProductID: r.ID,
+		Presence:  &presence,
+	}
+
+	for _, connections := range r.Clients {
+		for client := range connections {
+			r.deliver(client, message)
+		}
+	}
+}
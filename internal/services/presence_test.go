@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPresenceCountsRemoteWatchers(t *testing.T) {
+	room, _ := startRoom(t, SlowConsumerDropOldest)
+	other, another := uuid.New(), uuid.New()
+
+	remotes := []RemoteWatchers{
+		{Instance: other, Count: 2},
+		{Instance: another, Count: 3},
+		// A later count replaces the earlier one of the instance.
+		{Instance: other, Count: 4},
+	}
+	for _, remote := range remotes {
+		room.RemoteWatchers <- remote
+	}
+
+	client := NewFeedClient(nil, uuid.New())
+	if err := client.Subscribe(room); err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	snapshot := receive(t, client.Send, Snapshot)
+	if snapshot.Presence == nil || snapshot.Presence.Watchers != 8 {
+		t.Errorf("snapshot presence = %+v, want 8 watchers", snapshot.Presence)
+	}
+}
+
+func TestPresenceForgetsSilentInstances(t *testing.T) {
+	room := NewAuctionRoom(context.Background(), uuid.New(), nil, nil)
+	room.Clients[uuid.New()] = map[*Client]struct{}{}
+
+	fresh, silent := uuid.New(), uuid.New()
+	room.remoteWatchers[fresh] = remoteCount{count: 2, at: time.Now()}
+	room.remoteWatchers[silent] = remoteCount{count: 5, at: time.Now().Add(-PresenceRemoteTTL - time.Second)}
+
+	if presence := room.currentPresence(); presence.Watchers != 3 {
+		t.Errorf("watchers = %d, want 3", presence.Watchers)
+	}
+
+	if _, ok := room.remoteWatchers[silent]; ok {
+		t.Error("the silent instance was kept")
+	}
+}
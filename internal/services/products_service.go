@@ -29,6 +29,7 @@ func (ps *ProductService) CreateProduct(
 	name, description string,
 	basePrice float64,
 	auctionEnd time.Time,
+	category string,
 ) (uuid.UUID, error) {
 	id, err := ps.queries.CreateProduct(ctx, pg.CreateProductParams{
 		SellerID:    sellerID,
@@ -36,6 +37,7 @@ func (ps *ProductService) CreateProduct(
 		Description: description,
 		BasePrice:   basePrice,
 		AuctionEnd:  auctionEnd,
+		Category:    category,
 	})
 	if err != nil {
 		return uuid.Nil, err
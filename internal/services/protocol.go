@@ -0,0 +1,318 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Websocket subprotocols offered by the upgrader. Clients that don't request
+// one are served ProtocolV1, the original format with integer message types.
+const (
+	ProtocolV1 = "bid.v1"
+	ProtocolV2 = "bid.v2"
+)
+
+var Subprotocols = []string{ProtocolV2, ProtocolV1}
+
+// MessageType values are part of the v1 wire protocol and must never be
+// renumbered; append new types with the next free value.
+type MessageType int
+
+const (
+	// Requests
+	PlaceBid    MessageType = 0
+	Subscribe   MessageType = 7
+	Unsubscribe MessageType = 8
+	ClockSync   MessageType = 15
+
+	// Success
+	SuccessfullyPlacedBid MessageType = 1
+	Subscribed            MessageType = 9
+	Unsubscribed          MessageType = 10
+
+	// Info
+	NewBidPlaced   MessageType = 2
+	AuctionEnded   MessageType = 3
+	Snapshot       MessageType = 12
+	PresenceUpdate MessageType = 13
+	Tick           MessageType = 14
+	Outbid         MessageType = 16
+	Resync         MessageType = 17
+
+	// Errors
+	FailedToPlaceBid   MessageType = 4
+	InvalidJSON        MessageType = 5
+	SubscriptionFailed MessageType = 11
+
+	// Control
+	ConnectionClosed MessageType = 6
+)
+
+// Message is the event passed around inside an auction room. It doubles as
+// the v1 wire format, which leaves out the fields added since when they are
+// unset. Seq numbers room wide events, see AuctionRoom, and ResumeFrom asks a
+// subscribe request to replay the events after it.
+type Message struct {
+	Message    string      `json:"message,omitempty"`
+	UserID     uuid.UUID   `json:"user_id,omitempty"`
+	Amount     float64     `json:"amount,omitempty"`
+	Type       MessageType `json:"type"`
+	RequestID  string      `json:"request_id,omitempty"`
+	ProductID  uuid.UUID   `json:"product_id,omitzero"`
+	Seq        uint64      `json:"seq,omitempty"`
+	ResumeFrom uint64      `json:"resume_from,omitempty"`
+	Presence   *Presence   `json:"presence,omitempty"`
+	Clock      *Clock      `json:"clock,omitempty"`
+	BidID      uuid.UUID   `json:"-"`
+
+	// result receives the outcome of a bid placed through AuctionRoom.PlaceBid.
+	result chan bidResult
+}
+
+// Event names used as the envelope type in the v2 protocol.
+const (
+	EventPlaceBid           = "place_bid"
+	EventBidAccepted        = "bid_accepted"
+	EventNewBid             = "new_bid"
+	EventAuctionEnded       = "auction_ended"
+	EventBidRejected        = "bid_rejected"
+	EventInvalidMessage     = "invalid_message"
+	EventConnectionClosed   = "connection_closed"
+	EventSubscribe          = "subscribe"
+	EventUnsubscribe        = "unsubscribe"
+	EventSubscribed         = "subscribed"
+	EventUnsubscribed       = "unsubscribed"
+	EventSubscriptionFailed = "subscription_failed"
+	EventSnapshot           = "snapshot"
+	EventPresence           = "presence"
+	EventTick               = "tick"
+	EventClockSync          = "clock_sync"
+	EventOutbid             = "outbid"
+	EventResync             = "resync"
+)
+
+var eventNames = map[MessageType]string{
+	PlaceBid:              EventPlaceBid,
+	SuccessfullyPlacedBid: EventBidAccepted,
+	NewBidPlaced:          EventNewBid,
+	AuctionEnded:          EventAuctionEnded,
+	FailedToPlaceBid:      EventBidRejected,
+	InvalidJSON:           EventInvalidMessage,
+	ConnectionClosed:      EventConnectionClosed,
+	Subscribe:             EventSubscribe,
+	Unsubscribe:           EventUnsubscribe,
+	Subscribed:            EventSubscribed,
+	Unsubscribed:          EventUnsubscribed,
+	SubscriptionFailed:    EventSubscriptionFailed,
+	Snapshot:              EventSnapshot,
+	PresenceUpdate:        EventPresence,
+	Tick:                  EventTick,
+	ClockSync:             EventClockSync,
+	Outbid:                EventOutbid,
+	Resync:                EventResync,
+}
+
+// Envelope wraps every v2 message. ProductID names the auction a message is
+// about, which matters on multiplexed connections.
+type Envelope struct {
+	V         int             `json:"v"`
+	Type      string          `json:"type"`
+	RequestID string          `json:"request_id,omitempty"`
+	ProductID *uuid.UUID      `json:"product_id,omitempty"`
+	Seq       uint64          `json:"seq,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Timestamp time.Time       `json:"ts"`
+}
+
+type PlaceBidPayload struct {
+	Amount float64 `json:"amount"`
+}
+
+// SubscribePayload is optional. ResumeFrom is the seq of the last event the
+// client got from the auction, to be sent the ones it missed.
+type SubscribePayload struct {
+	ResumeFrom uint64 `json:"resume_from"`
+}
+
+type BidAcceptedPayload struct {
+	BidID  uuid.UUID `json:"bid_id"`
+	Amount float64   `json:"amount"`
+}
+
+type NewBidPayload struct {
+	BidderID uuid.UUID `json:"bidder_id"`
+	Amount   float64   `json:"amount"`
+}
+
+// SnapshotPayload is sent to a client when it joins an auction.
+type SnapshotPayload struct {
+	Presence Presence `json:"presence"`
+	Clock    *Clock   `json:"clock,omitempty"`
+}
+
+// ClockSyncPayload is sent by clients with their own time, in any unit, and
+// echoed back next to the server time.
+type ClockSyncPayload struct {
+	ClientTime int64 `json:"client_time"`
+}
+
+// OutbidPayload is sent to the previous high bidder with the amount of the
+// bid that beat theirs.
+type OutbidPayload struct {
+	Amount float64 `json:"amount"`
+}
+
+// ResyncPayload is sent instead of replaying events when a client missed too
+// many. The envelope seq is the latest event of the auction, to resume from
+// once the client reloaded its state.
+type ResyncPayload struct {
+	Reason string `json:"reason"`
+}
+
+type AuctionEndedPayload struct {
+	Message string `json:"message"`
+}
+
+// ErrorPayload is sent with bid_rejected, invalid_message and
+// connection_closed.
+type ErrorPayload struct {
+	Reason string `json:"reason"`
+}
+
+var errUnsupportedMessage = errors.New("unsupported message")
+
+// decodeMessage parses a frame sent by a client speaking protocol.
+func decodeMessage(protocol string, data []byte) (Message, error) {
+	if protocol != ProtocolV2 {
+		var message Message
+		err := json.Unmarshal(data, &message)
+		return message, err
+	}
+
+	var envelope Envelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return Message{}, err
+	}
+
+	message := Message{RequestID: envelope.RequestID}
+	if envelope.ProductID != nil {
+		message.ProductID = *envelope.ProductID
+	}
+
+	if envelope.V != 2 {
+		return message, errUnsupportedMessage
+	}
+
+	switch envelope.Type {
+	case EventPlaceBid:
+		var payload PlaceBidPayload
+		if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+			return message, err
+		}
+
+		message.Type = PlaceBid
+		message.Amount = payload.Amount
+	case EventSubscribe:
+		if len(envelope.Payload) > 0 {
+			var payload SubscribePayload
+			if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+				return message, err
+			}
+
+			message.ResumeFrom = payload.ResumeFrom
+		}
+
+		message.Type = Subscribe
+	case EventUnsubscribe:
+		message.Type = Unsubscribe
+	case EventClockSync:
+		if len(envelope.Payload) > 0 {
+			var payload ClockSyncPayload
+			if err := json.Unmarshal(envelope.Payload, &payload); err != nil {
+				return message, err
+			}
+
+			message.Clock = &Clock{ClientTime: payload.ClientTime}
+		}
+
+		message.Type = ClockSync
+	default:
+		return message, errUnsupportedMessage
+	}
+
+	return message, nil
+}
+
+// encodeMessage renders message in the wire format of protocol.
+func encodeMessage(protocol string, message Message) (any, error) {
+	if protocol != ProtocolV2 {
+		return message, nil
+	}
+
+	var payload any
+	switch message.Type {
+	case SuccessfullyPlacedBid:
+		payload = BidAcceptedPayload{BidID: message.BidID, Amount: message.Amount}
+	case NewBidPlaced:
+		payload = NewBidPayload{BidderID: message.UserID, Amount: message.Amount}
+	case Outbid:
+		payload = OutbidPayload{Amount: message.Amount}
+	case AuctionEnded:
+		payload = AuctionEndedPayload{Message: message.Message}
+	case Resync:
+		payload = ResyncPayload{Reason: message.Message}
+	case Snapshot:
+		payload = SnapshotPayload{Presence: *message.Presence, Clock: message.Clock}
+	case PresenceUpdate:
+		payload = *message.Presence
+	case Tick, ClockSync:
+		payload = message.Clock
+	case FailedToPlaceBid, InvalidJSON, ConnectionClosed, SubscriptionFailed:
+		payload = ErrorPayload{Reason: message.Message}
+	case Subscribed, Unsubscribed:
+	default:
+		return nil, errUnsupportedMessage
+	}
+
+	envelope := Envelope{
+		V:         2,
+		Type:      eventNames[message.Type],
+		RequestID: message.RequestID,
+		Seq:       message.Seq,
+		Timestamp: time.Now().UTC(),
+	}
+
+	if message.ProductID != uuid.Nil {
+		envelope.ProductID = &message.ProductID
+	}
+
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		envelope.Payload = raw
+	}
+
+	return envelope, nil
+}
+
+// EventName returns the v2 event name of a message type.
+func EventName(t MessageType) string {
+	return eventNames[t]
+}
+
+// MarshalEnvelope encodes message as a v2 envelope, e.g. to stream it as a
+// server-sent event.
+func MarshalEnvelope(message Message) ([]byte, error) {
+	envelope, err := encodeMessage(ProtocolV2, message)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(envelope)
+}
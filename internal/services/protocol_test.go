@@ -0,0 +1,47 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestEncodeMessageV1(t *testing.T) {
+	productID := uuid.MustParse("7d4f2c1e-5b1a-4c3e-9f0d-2a6b8e1c3d5f")
+
+	tests := []struct {
+		name    string
+		message Message
+		want    string
+	}{
+		{
+			name:    "without product",
+			message: Message{Type: InvalidJSON, Message: "invalid json"},
+			want:    `{"message":"invalid json","user_id":"00000000-0000-0000-0000-000000000000","type":5}`,
+		},
+		{
+			name:    "with product",
+			message: Message{Type: AuctionEnded, Message: "auction has ended", ProductID: productID},
+			want:    `{"message":"auction has ended","user_id":"00000000-0000-0000-0000-000000000000","type":3,"product_id":"` + productID.String() + `"}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			encoded, err := encodeMessage(ProtocolV1, test.message)
+			if err != nil {
+				t.Fatalf("encode: %v", err)
+			}
+
+			got, err := json.Marshal(encoded)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != test.want {
+				t.Errorf("frame = %s, want %s", got, test.want)
+			}
+		})
+	}
+}
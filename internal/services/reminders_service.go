@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// ReminderService writes the auction.ending_soon event of the auctions
+// about to end, once each.
+type ReminderService struct {
+	pool    *pgxpool.Pool
+	queries *pg.Queries
+}
+
+func NewReminderService(pool *pgxpool.Pool) ReminderService {
+	return ReminderService{
+		pool:    pool,
+		queries: pg.New(pool),
+	}
+}
+
+// Run sends reminders until ctx is done.
+func (rs *ReminderService) Run(ctx context.Context) {
+	ticker := time.NewTicker(ReminderInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			sent, err := rs.remind(ctx)
+			if err != nil {
+				slog.Error("Failed to send auction reminders", "Error", err)
+				break
+			}
+
+			if sent < ReminderBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// remind claims a batch of auctions ending within EndingSoonWindow and writes
+// their events in the same transaction, so that none is skipped or sent
+// twice. It returns the size of the batch.
+func (rs *ReminderService) remind(ctx context.Context) (int, error) {
+	tx, err := rs.pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := rs.queries.WithTx(tx)
+	products, err := qtx.ClaimEndingSoonProducts(ctx, pg.ClaimEndingSoonProductsParams{
+		EndsBefore:  time.Now().Add(EndingSoonWindow),
+		MaxProducts: ReminderBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, product := range products {
+		bidderIDs, err := qtx.ListBidderIDsByProductID(ctx, product.ID)
+		if err != nil {
+			return 0, err
+		}
+
+		// Sellers may want to know even if no one bid yet.
+		err = enqueueOutboxEvent(ctx, qtx, TopicAuctionEndingSoon, product.ID, AuctionEndingSoonEvent{
+			ProductID: product.ID,
+			SellerID:  product.SellerID,
+			EndsAt:    product.AuctionEnd,
+			BidderIDs: bidderIDs,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+
+	return len(products), nil
+}
@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// SecondChanceService lets sellers offer the item of a defaulted order to the
+// runner-up bidders, one at a time, at their highest bid. Offers are open for
+// SecondChanceOfferTTL; an accepted offer becomes a new order awaiting
+// payment, and a declined or expired one makes way for the next bidder.
+type SecondChanceService struct {
+	pool    *pgxpool.Pool
+	queries *pg.Queries
+}
+
+func NewSecondChanceService(pool *pgxpool.Pool) SecondChanceService {
+	return SecondChanceService{
+		pool:    pool,
+		queries: pg.New(pool),
+	}
+}
+
+// MakeOffer offers the item of a defaulted order of the seller to the next
+// eligible bidder: the highest one who neither bought it before nor got an
+// offer for it yet, and whose account is active.
+func (scs *SecondChanceService) MakeOffer(ctx context.Context, userID, orderID uuid.UUID) (pg.SecondChanceOffer, error) {
+	tx, err := scs.pool.Begin(ctx)
+	if err != nil {
+		return pg.SecondChanceOffer{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := scs.queries.WithTx(tx)
+	order, err := qtx.GetOrderByIDForUpdate(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pg.SecondChanceOffer{}, ErrOrderNotFound
+		}
+
+		return pg.SecondChanceOffer{}, err
+	}
+
+	switch actor := orderActor(order, userID); {
+	case actor == "":
+		return pg.SecondChanceOffer{}, ErrOrderNotFound
+	case actor != OrderActorSeller || order.Status != OrderDefaulted:
+		return pg.SecondChanceOffer{}, ErrSecondChanceUnavailable
+	}
+
+	bid, err := scs.nextEligibleBid(ctx, qtx, order.ProductID)
+	if err != nil {
+		return pg.SecondChanceOffer{}, err
+	}
+
+	offer, err := qtx.CreateSecondChanceOffer(ctx, pg.CreateSecondChanceOfferParams{
+		OrderID:   order.ID,
+		ProductID: order.ProductID,
+		SellerID:  order.SellerID,
+		BidderID:  bid.BidderID,
+		BidID:     bid.ID,
+		Amount:    bid.Amount,
+		ExpiresAt: time.Now().Add(SecondChanceOfferTTL),
+	})
+	if err != nil {
+		// Another offer for the product was made meanwhile.
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == PgErrCodeUniqueViolation {
+			return pg.SecondChanceOffer{}, ErrSecondChanceUnavailable
+		}
+
+		return pg.SecondChanceOffer{}, err
+	}
+
+	err = enqueueOutboxEvent(ctx, qtx, TopicSecondChanceOffered, offer.ID, newSecondChanceOfferEvent(offer))
+	if err != nil {
+		return pg.SecondChanceOffer{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return pg.SecondChanceOffer{}, err
+	}
+
+	return offer, nil
+}
+
+// nextEligibleBid returns the highest bid on the product of a bidder who can
+// get a second-chance offer, see eligibleBid.
+func (scs *SecondChanceService) nextEligibleBid(ctx context.Context, qtx *pg.Queries, productID uuid.UUID) (pg.Bid, error) {
+	orders, err := qtx.ListOrdersByProductID(ctx, productID)
+	if err != nil {
+		return pg.Bid{}, err
+	}
+
+	offers, err := qtx.ListSecondChanceOffersByProductID(ctx, productID)
+	if err != nil {
+		return pg.Bid{}, err
+	}
+
+	bids, err := qtx.GetBidsByProductID(ctx, productID)
+	if err != nil {
+		return pg.Bid{}, err
+	}
+
+	return eligibleBid(orders, offers, bids, func(bidderID uuid.UUID) (bool, error) {
+		bidder, err := qtx.GetUserByID(ctx, bidderID)
+		if err != nil {
+			return false, err
+		}
+
+		return bidder.SuspendedAt != nil, nil
+	})
+}
+
+// eligibleBid returns, among bids sorted highest first, the best bid of a
+// bidder who neither bought the product before nor got an offer for it yet,
+// and who is not suspended. There is none while the product has an order
+// going on or an offer open.
+func eligibleBid(
+	orders []pg.Order,
+	offers []pg.SecondChanceOffer,
+	bids []pg.Bid,
+	suspended func(bidderID uuid.UUID) (bool, error),
+) (pg.Bid, error) {
+	excluded := make(map[uuid.UUID]struct{})
+
+	for _, order := range orders {
+		if order.Status != OrderCancelled && order.Status != OrderDefaulted {
+			return pg.Bid{}, ErrSecondChanceUnavailable
+		}
+
+		excluded[order.BuyerID] = struct{}{}
+	}
+
+	for _, offer := range offers {
+		if offer.Status == SecondChancePending {
+			return pg.Bid{}, ErrSecondChanceUnavailable
+		}
+
+		excluded[offer.BidderID] = struct{}{}
+	}
+
+	// The first bid of a bidder is their best.
+	for _, bid := range bids {
+		if _, ok := excluded[bid.BidderID]; ok {
+			continue
+		}
+		excluded[bid.BidderID] = struct{}{}
+
+		isSuspended, err := suspended(bid.BidderID)
+		if err != nil {
+			return pg.Bid{}, err
+		}
+
+		if !isSuspended {
+			return bid, nil
+		}
+	}
+
+	return pg.Bid{}, ErrNoEligibleBidder
+}
+
+// ListOffers returns the offers the user made as a seller or got as a
+// bidder, newest first.
+func (scs *SecondChanceService) ListOffers(ctx context.Context, userID uuid.UUID) ([]pg.SecondChanceOffer, error) {
+	offers, err := scs.queries.ListSecondChanceOffersByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if offers == nil {
+		offers = []pg.SecondChanceOffer{}
+	}
+
+	return offers, nil
+}
+
+// AcceptOffer accepts an open offer made to the bidder and returns the order
+// created for them, awaiting payment.
+func (scs *SecondChanceService) AcceptOffer(ctx context.Context, userID, offerID uuid.UUID) (pg.Order, error) {
+	tx, err := scs.pool.Begin(ctx)
+	if err != nil {
+		return pg.Order{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := scs.queries.WithTx(tx)
+	offer, err := scs.respond(ctx, qtx, userID, offerID, SecondChanceAccepted)
+	if err != nil {
+		return pg.Order{}, err
+	}
+
+	product, err := qtx.GetProductByID(ctx, offer.ProductID)
+	if err != nil {
+		return pg.Order{}, err
+	}
+
+	quote, err := quoteFee(ctx, qtx, product.Category, offer.Amount)
+	if err != nil {
+		return pg.Order{}, err
+	}
+
+	order, err := qtx.CreateOrder(ctx, pg.CreateOrderParams{
+		ProductID:    offer.ProductID,
+		SellerID:     offer.SellerID,
+		BuyerID:      offer.BidderID,
+		BidID:        offer.BidID,
+		FinalPrice:   offer.Amount,
+		PaymentDueAt: time.Now().Add(OrderPaymentWindow),
+		Fee:          quote.Fee,
+		NetProceeds:  quote.NetProceeds,
+		FeeRuleID:    quote.RuleID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pg.Order{}, ErrSecondChanceClosed
+		}
+
+		return pg.Order{}, err
+	}
+
+	err = enqueueOutboxEvent(ctx, qtx, TopicOrderCreated, order.ID, newOrderCreatedEvent(order))
+	if err != nil {
+		return pg.Order{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return pg.Order{}, err
+	}
+
+	return order, nil
+}
+
+// DeclineOffer declines an open offer made to the bidder.
+func (scs *SecondChanceService) DeclineOffer(ctx context.Context, userID, offerID uuid.UUID) (pg.SecondChanceOffer, error) {
+	tx, err := scs.pool.Begin(ctx)
+	if err != nil {
+		return pg.SecondChanceOffer{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	offer, err := scs.respond(ctx, scs.queries.WithTx(tx), userID, offerID, SecondChanceDeclined)
+	if err != nil {
+		return pg.SecondChanceOffer{}, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return pg.SecondChanceOffer{}, err
+	}
+
+	return offer, nil
+}
+
+// respond moves an open offer made to the bidder to status and writes its
+// event. Offers made to other users are reported as not found.
+func (scs *SecondChanceService) respond(
+	ctx context.Context,
+	qtx *pg.Queries,
+	userID, offerID uuid.UUID,
+	status string,
+) (pg.SecondChanceOffer, error) {
+	offer, err := qtx.GetSecondChanceOfferByIDForUpdate(ctx, offerID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pg.SecondChanceOffer{}, ErrSecondChanceNotFound
+		}
+
+		return pg.SecondChanceOffer{}, err
+	}
+
+	if err := canRespond(offer, userID, time.Now()); err != nil {
+		return pg.SecondChanceOffer{}, err
+	}
+
+	offer, err = qtx.RespondToSecondChanceOffer(ctx, pg.RespondToSecondChanceOfferParams{
+		Status: status,
+		ID:     offer.ID,
+	})
+	if err != nil {
+		return pg.SecondChanceOffer{}, err
+	}
+
+	topic := TopicSecondChanceDeclined
+	if status == SecondChanceAccepted {
+		topic = TopicSecondChanceAccepted
+	}
+
+	if err := enqueueOutboxEvent(ctx, qtx, topic, offer.ID, newSecondChanceOfferEvent(offer)); err != nil {
+		return pg.SecondChanceOffer{}, err
+	}
+
+	return offer, nil
+}
+
+// canRespond reports why the user can't respond to the offer at now, if so.
+// Offers close when they expire, even before DeadlineService marks them
+// expired.
+func canRespond(offer pg.SecondChanceOffer, userID uuid.UUID, now time.Time) error {
+	if offer.BidderID != userID {
+		return ErrSecondChanceNotFound
+	}
+
+	if offer.Status != SecondChancePending || !now.Before(offer.ExpiresAt) {
+		return ErrSecondChanceClosed
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+func TestEligibleBid(t *testing.T) {
+	winner, runnerUp, third, fourth := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	bid := func(bidderID uuid.UUID, amount float64) pg.Bid {
+		return pg.Bid{ID: uuid.New(), BidderID: bidderID, Amount: amount}
+	}
+
+	// Highest first, as GetBidsByProductID returns them.
+	bids := []pg.Bid{
+		bid(winner, 100),
+		bid(runnerUp, 90),
+		bid(winner, 80),
+		bid(third, 70),
+		bid(runnerUp, 60),
+		bid(fourth, 50),
+	}
+	defaulted := pg.Order{BuyerID: winner, Status: OrderDefaulted}
+
+	tests := []struct {
+		name      string
+		orders    []pg.Order
+		offers    []pg.SecondChanceOffer
+		suspended []uuid.UUID
+		bid       pg.Bid
+		err       error
+	}{
+		{
+			name:   "runner-up after the defaulted buyer",
+			orders: []pg.Order{defaulted},
+			bid:    bids[1],
+		},
+		{
+			name:   "previous buyers",
+			orders: []pg.Order{defaulted, {BuyerID: runnerUp, Status: OrderCancelled}},
+			bid:    bids[3],
+		},
+		{
+			name:   "earlier offers",
+			orders: []pg.Order{defaulted},
+			offers: []pg.SecondChanceOffer{
+				{BidderID: runnerUp, Status: SecondChanceDeclined},
+				{BidderID: third, Status: SecondChanceExpired},
+			},
+			bid: bids[5],
+		},
+		{
+			name:      "suspended bidders",
+			orders:    []pg.Order{defaulted},
+			suspended: []uuid.UUID{runnerUp, third},
+			bid:       bids[5],
+		},
+		{
+			name:   "accepted offer whose order defaulted",
+			orders: []pg.Order{defaulted, {BuyerID: runnerUp, Status: OrderDefaulted}},
+			offers: []pg.SecondChanceOffer{{BidderID: runnerUp, Status: SecondChanceAccepted}},
+			bid:    bids[3],
+		},
+		{
+			name:   "offer still pending",
+			orders: []pg.Order{defaulted},
+			offers: []pg.SecondChanceOffer{{BidderID: runnerUp, Status: SecondChancePending}},
+			err:    ErrSecondChanceUnavailable,
+		},
+		{
+			name:   "order going on",
+			orders: []pg.Order{defaulted, {BuyerID: runnerUp, Status: OrderAwaitingPayment}},
+			err:    ErrSecondChanceUnavailable,
+		},
+		{
+			name:      "no bidder left",
+			orders:    []pg.Order{defaulted},
+			offers:    []pg.SecondChanceOffer{{BidderID: runnerUp, Status: SecondChanceDeclined}},
+			suspended: []uuid.UUID{third, fourth},
+			err:       ErrNoEligibleBidder,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			suspended := func(bidderID uuid.UUID) (bool, error) {
+				for _, id := range test.suspended {
+					if id == bidderID {
+						return true, nil
+					}
+				}
+
+				return false, nil
+			}
+
+			got, err := eligibleBid(test.orders, test.offers, bids, suspended)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("error = %v, want %v", err, test.err)
+			}
+
+			if got != test.bid {
+				t.Errorf("bid = %+v, want %+v", got, test.bid)
+			}
+		})
+	}
+}
+
+func TestCanRespond(t *testing.T) {
+	bidderID := uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		userID    uuid.UUID
+		status    string
+		expiresAt time.Time
+		err       error
+	}{
+		{
+			name:      "open offer",
+			userID:    bidderID,
+			status:    SecondChancePending,
+			expiresAt: now.Add(time.Minute),
+		},
+		{
+			name:      "offer made to another bidder",
+			userID:    uuid.New(),
+			status:    SecondChancePending,
+			expiresAt: now.Add(time.Minute),
+			err:       ErrSecondChanceNotFound,
+		},
+		{
+			name:      "offer past its deadline",
+			userID:    bidderID,
+			status:    SecondChancePending,
+			expiresAt: now,
+			err:       ErrSecondChanceClosed,
+		},
+		{
+			name:      "expired offer",
+			userID:    bidderID,
+			status:    SecondChanceExpired,
+			expiresAt: now.Add(-time.Minute),
+			err:       ErrSecondChanceClosed,
+		},
+		{
+			name:      "accepted offer",
+			userID:    bidderID,
+			status:    SecondChanceAccepted,
+			expiresAt: now.Add(time.Minute),
+			err:       ErrSecondChanceClosed,
+		},
+		{
+			name:      "declined offer",
+			userID:    bidderID,
+			status:    SecondChanceDeclined,
+			expiresAt: now.Add(time.Minute),
+			err:       ErrSecondChanceClosed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			offer := pg.SecondChanceOffer{
+				BidderID:  bidderID,
+				Status:    test.status,
+				ExpiresAt: test.expiresAt,
+			}
+
+			if err := canRespond(offer, test.userID, now); !errors.Is(err, test.err) {
+				t.Errorf("error = %v, want %v", err, test.err)
+			}
+		})
+	}
+}
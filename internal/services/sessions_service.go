@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// SessionInfo describes one of the user's active sessions. The session token
+// itself is never exposed.
+type SessionInfo struct {
+	ID         uuid.UUID `json:"id"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// SessionService keeps per device metadata for the opaque scs sessions stored
+// by pgxstore, keyed by session token.
+type SessionService struct {
+	pool    *pgxpool.Pool
+	queries *pg.Queries
+}
+
+func NewSessionService(pool *pgxpool.Pool) SessionService {
+	return SessionService{
+		pool:    pool,
+		queries: pg.New(pool),
+	}
+}
+
+func (ss *SessionService) RecordSession(ctx context.Context, token string, userID uuid.UUID, ipAddress, userAgent string) error {
+	if err := ss.queries.DeleteStaleUserSessions(ctx, userID); err != nil {
+		return err
+	}
+
+	return ss.queries.CreateUserSession(ctx, pg.CreateUserSessionParams{
+		Token:     token,
+		UserID:    userID,
+		IpAddress: ipAddress,
+		UserAgent: userAgent,
+	})
+}
+
+func (ss *SessionService) TouchSession(ctx context.Context, token string) error {
+	return ss.queries.TouchUserSession(ctx, token)
+}
+
+// RotateToken keeps the metadata attached to a session whose token was renewed.
+func (ss *SessionService) RotateToken(ctx context.Context, oldToken, newToken string) error {
+	return ss.queries.RotateUserSessionToken(ctx, pg.RotateUserSessionTokenParams{
+		NewToken: newToken,
+		OldToken: oldToken,
+	})
+}
+
+func (ss *SessionService) ForgetSession(ctx context.Context, token string) error {
+	return ss.queries.DeleteUserSessionByToken(ctx, token)
+}
+
+func (ss *SessionService) ListSessions(ctx context.Context, userID uuid.UUID, currentToken string) ([]SessionInfo, error) {
+	rows, err := ss.queries.ListUserSessions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]SessionInfo, 0, len(rows))
+	for _, row := range rows {
+		sessions = append(sessions, SessionInfo{
+			ID:         row.ID,
+			IPAddress:  row.IpAddress,
+			UserAgent:  row.UserAgent,
+			CreatedAt:  row.CreatedAt,
+			LastSeenAt: row.LastSeenAt,
+			ExpiresAt:  row.Expiry,
+			Current:    row.Token == currentToken,
+		})
+	}
+
+	return sessions, nil
+}
+
+func (ss *SessionService) RevokeSession(ctx context.Context, userID, sessionID uuid.UUID) error {
+	rows, err := ss.queries.RevokeUserSession(ctx, pg.RevokeUserSessionParams{
+		ID:     sessionID,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+// RevokeOtherSessions logs the user out of every session except currentToken
+// and returns how many were revoked.
+func (ss *SessionService) RevokeOtherSessions(ctx context.Context, userID uuid.UUID, currentToken string) (int64, error) {
+	return ss.queries.RevokeOtherUserSessions(ctx, pg.RevokeOtherUserSessionsParams{
+		UserID: userID,
+		Token:  currentToken,
+	})
+}
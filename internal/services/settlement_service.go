@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// SettlementService closes the books of ended auctions: the product is
+// marked sold to the highest bidder, if any, who gets an order awaiting
+// payment, charged the fee of the matching fee rule. The auction.ended,
+// item.sold and order.created events are written to the outbox in the same
+// transaction.
+type SettlementService struct {
+	pool    *pgxpool.Pool
+	queries *pg.Queries
+}
+
+func NewSettlementService(pool *pgxpool.Pool) SettlementService {
+	return SettlementService{
+		pool:    pool,
+		queries: pg.New(pool),
+	}
+}
+
+// SettleAuction settles an ended auction. Settling it again does nothing, so
+// every instance may try.
+func (ss *SettlementService) SettleAuction(ctx context.Context, productID uuid.UUID) error {
+	tx, err := ss.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	qtx := ss.queries.WithTx(tx)
+	product, err := qtx.GetProductByIDForUpdate(ctx, productID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrProductNotFound
+		}
+
+		return err
+	}
+
+	if product.SettledAt != nil {
+		return nil
+	}
+
+	if product.AuctionEnd.After(time.Now()) {
+		return ErrAuctionNotEnded
+	}
+
+	winningBid, err := qtx.GetHighestBidByProductID(ctx, productID)
+	sold := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	err = qtx.SettleProduct(ctx, pg.SettleProductParams{ID: productID, IsSold: sold})
+	if err != nil {
+		return err
+	}
+
+	ended := AuctionEndedEvent{
+		ProductID: productID,
+		SellerID:  product.SellerID,
+		EndedAt:   product.AuctionEnd,
+	}
+
+	if sold {
+		ended.WinnerID = &winningBid.BidderID
+		ended.BidID = &winningBid.ID
+		ended.FinalPrice = winningBid.Amount
+	}
+
+	if err := enqueueOutboxEvent(ctx, qtx, TopicAuctionEnded, productID, ended); err != nil {
+		return err
+	}
+
+	if sold {
+		err = enqueueOutboxEvent(ctx, qtx, TopicItemSold, productID, ItemSoldEvent{
+			ProductID:  productID,
+			SellerID:   product.SellerID,
+			WinnerID:   winningBid.BidderID,
+			BidID:      winningBid.ID,
+			FinalPrice: winningBid.Amount,
+		})
+		if err != nil {
+			return err
+		}
+
+		quote, err := quoteFee(ctx, qtx, product.Category, winningBid.Amount)
+		if err != nil {
+			return err
+		}
+
+		order, err := qtx.CreateOrder(ctx, pg.CreateOrderParams{
+			ProductID:    productID,
+			SellerID:     product.SellerID,
+			BuyerID:      winningBid.BidderID,
+			BidID:        winningBid.ID,
+			FinalPrice:   winningBid.Amount,
+			PaymentDueAt: time.Now().Add(OrderPaymentWindow),
+			Fee:          quote.Fee,
+			NetProceeds:  quote.NetProceeds,
+			FeeRuleID:    quote.RuleID,
+		})
+		if err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return tx.Commit(ctx)
+			}
+
+			return err
+		}
+
+		err = enqueueOutboxEvent(ctx, qtx, TopicOrderCreated, order.ID, newOrderCreatedEvent(order))
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Run settles, until ctx is done, the ended auctions no room settled, e.g.
+// because no instance had them open when they ended.
+func (ss *SettlementService) Run(ctx context.Context) {
+	ticker := time.NewTicker(SettlementInterval)
+	defer ticker.Stop()
+
+	for {
+		productIDs, err := ss.queries.ListUnsettledProducts(ctx, SettlementBatchSize)
+		if err != nil {
+			slog.Error("Failed to list unsettled auctions", "Error", err)
+		}
+
+		for _, productID := range productIDs {
+			if err := ss.SettleAuction(ctx, productID); err != nil {
+				slog.Error("Failed to settle auction", "ProductID", productID, "Error", err)
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
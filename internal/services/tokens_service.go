@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+type TokenService struct {
+	pool    *pgxpool.Pool
+	queries *pg.Queries
+}
+
+func NewTokenService(pool *pgxpool.Pool) TokenService {
+	return TokenService{
+		pool:    pool,
+		queries: pg.New(pool),
+	}
+}
+
+// CreateToken stores a new token for the user and returns its plain text value
+// alongside the stored row. The plain text value is never persisted, so this is
+// the only time it can be shown to the user.
+func (ts *TokenService) CreateToken(
+	ctx context.Context,
+	userID uuid.UUID,
+	name string,
+	scopes []string,
+) (string, pg.CreateApiTokenRow, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", pg.CreateApiTokenRow{}, err
+	}
+
+	plain := ApiTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	token, err := ts.queries.CreateApiToken(ctx, pg.CreateApiTokenParams{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashToken(plain),
+		Scopes:    scopes,
+	})
+	if err != nil {
+		return "", pg.CreateApiTokenRow{}, err
+	}
+
+	return plain, token, nil
+}
+
+func (ts *TokenService) ListTokens(ctx context.Context, userID uuid.UUID) ([]pg.ListApiTokensByUserIDRow, error) {
+	tokens, err := ts.queries.ListApiTokensByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokens == nil {
+		tokens = []pg.ListApiTokensByUserIDRow{}
+	}
+
+	return tokens, nil
+}
+
+func (ts *TokenService) RevokeToken(ctx context.Context, userID, tokenID uuid.UUID) error {
+	rows, err := ts.queries.RevokeApiToken(ctx, pg.RevokeApiTokenParams{
+		ID:     tokenID,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrTokenNotFound
+	}
+
+	return nil
+}
+
+// Authenticate resolves a plain text token to its stored row and records the
+// time it was used.
+func (ts *TokenService) Authenticate(ctx context.Context, plain string) (pg.ApiToken, error) {
+	token, err := ts.queries.UseApiToken(ctx, hashToken(plain))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pg.ApiToken{}, ErrInvalidToken
+		}
+
+		return pg.ApiToken{}, err
+	}
+
+	return token, nil
+}
+
+// HasScope reports whether the token was granted scope.
+func HasScope(token pg.ApiToken, scope string) bool {
+	return slices.Contains(token.Scopes, scope)
+}
+
+func hashToken(plain string) []byte {
+	sum := sha256.Sum256([]byte(plain))
+	return sum[:]
+}
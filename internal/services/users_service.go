@@ -65,7 +65,121 @@ func (us *UserService) AuthenticateUser(ctx context.Context, email, password str
 		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
 			return uuid.Nil, ErrInvalidCredentials
 		}
+
+		return uuid.Nil, err
+	}
+
+	if user.SuspendedAt != nil {
+		return uuid.Nil, ErrAccountSuspended
 	}
 
 	return user.ID, nil
 }
+
+func (us *UserService) GetUserByID(ctx context.Context, userID uuid.UUID) (pg.GetUserByIDRow, error) {
+	user, err := us.queries.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pg.GetUserByIDRow{}, ErrUserNotFound
+		}
+
+		return pg.GetUserByIDRow{}, err
+	}
+
+	return user, nil
+}
+
+// EnsureActive returns ErrAccountSuspended if the user has been suspended and
+// ErrUserNotFound if the account no longer exists.
+func (us *UserService) EnsureActive(ctx context.Context, userID uuid.UUID) error {
+	user, err := us.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.SuspendedAt != nil {
+		return ErrAccountSuspended
+	}
+
+	return nil
+}
+
+// SuspendUser suspends the user on behalf of the moderator, who may only
+// suspend other users of a lower role.
+func (us *UserService) SuspendUser(ctx context.Context, moderatorID, userID uuid.UUID) error {
+	moderator, err := us.GetUserByID(ctx, moderatorID)
+	if err != nil {
+		return err
+	}
+
+	user, err := us.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if err := canSuspend(moderator, user); err != nil {
+		return err
+	}
+
+	rows, err := us.queries.SuspendUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func canSuspend(moderator, user pg.GetUserByIDRow) error {
+	if moderator.ID == user.ID {
+		return ErrCannotSuspendSelf
+	}
+
+	if roleRanks[user.Role] >= roleRanks[moderator.Role] {
+		return ErrRoleTooHigh
+	}
+
+	return nil
+}
+
+func (us *UserService) ReinstateUser(ctx context.Context, userID uuid.UUID) error {
+	rows, err := us.queries.ReinstateUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrUserNotFound
+	}
+
+	return nil
+}
+
+func (us *UserService) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) error {
+	user, err := us.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	err = bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(currentPassword))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrInvalidCredentials
+		}
+
+		return err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	return us.queries.UpdateUserPassword(ctx, pg.UpdateUserPasswordParams{
+		ID:           userID,
+		PasswordHash: hash,
+	})
+}
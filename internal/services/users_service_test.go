@@ -0,0 +1,40 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+func TestCanSuspend(t *testing.T) {
+	user := func(role string) pg.GetUserByIDRow {
+		return pg.GetUserByIDRow{ID: uuid.New(), Role: role}
+	}
+	moderator, admin := user(RoleModerator), user(RoleAdmin)
+
+	tests := []struct {
+		name      string
+		moderator pg.GetUserByIDRow
+		user      pg.GetUserByIDRow
+		err       error
+	}{
+		{name: "moderator suspends user", moderator: moderator, user: user(RoleUser)},
+		{name: "admin suspends user", moderator: admin, user: user(RoleUser)},
+		{name: "admin suspends moderator", moderator: admin, user: user(RoleModerator)},
+		{name: "moderator suspends moderator", moderator: moderator, user: user(RoleModerator), err: ErrRoleTooHigh},
+		{name: "moderator suspends admin", moderator: moderator, user: user(RoleAdmin), err: ErrRoleTooHigh},
+		{name: "admin suspends admin", moderator: admin, user: user(RoleAdmin), err: ErrRoleTooHigh},
+		{name: "moderator suspends themselves", moderator: moderator, user: moderator, err: ErrCannotSuspendSelf},
+		{name: "admin suspends themselves", moderator: admin, user: admin, err: ErrCannotSuspendSelf},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := canSuspend(test.moderator, test.user); !errors.Is(err, test.err) {
+				t.Errorf("error = %v, want %v", err, test.err)
+			}
+		})
+	}
+}
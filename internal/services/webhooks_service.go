@@ -0,0 +1,474 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+// WebhookService manages the webhooks of users and delivers them the outbox
+// events they subscribed to. It is an OutboxSink, which only records a
+// delivery per webhook; Run then sends them, retrying failures with
+// exponential backoff.
+type WebhookService struct {
+	pool    *pgxpool.Pool
+	queries *pg.Queries
+	client  *http.Client
+}
+
+func NewWebhookService(pool *pgxpool.Pool) WebhookService {
+	return WebhookService{
+		pool:    pool,
+		queries: pg.New(pool),
+		client:  newWebhookClient(),
+	}
+}
+
+// newWebhookClient returns the client deliveries are sent with. It refuses
+// to connect to addresses that are not public, checked on the resolved
+// address at dial time so a hostname can't be rebound to an internal one
+// after the webhook was created, and never follows redirects.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: WebhookTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+
+			ip, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+
+			if !IsPublicAddr(ip) {
+				return fmt.Errorf("%w: %s", ErrForbiddenWebhookAddress, ip)
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: WebhookTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: WebhookTimeout,
+			MaxIdleConns:        WebhookBatchSize,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// CheckWebhookURL checks that rawURL is an absolute http or https URL whose
+// host resolves to public addresses only.
+func CheckWebhookURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return ErrInvalidWebhookURL
+	}
+
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", u.Hostname())
+	if err != nil {
+		return err
+	}
+
+	for _, ip := range ips {
+		if !IsPublicAddr(ip) {
+			return fmt.Errorf("%w: %s", ErrForbiddenWebhookAddress, ip)
+		}
+	}
+
+	return nil
+}
+
+// IsPublicAddr reports whether ip is routable on the internet, as opposed to
+// loopback, private, link-local (such as the 169.254.169.254 metadata
+// endpoint of cloud providers), shared, unspecified or multicast addresses.
+func IsPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() ||
+		ip.IsLoopback() ||
+		ip.IsPrivate() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() ||
+		ip.IsMulticast() ||
+		ip.IsUnspecified() {
+		return false
+	}
+
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nonPublicPrefixes are the ranges IsPublicAddr rejects on top of those
+// netip knows about.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// WebhookPayload is the body of every webhook request. ID is stable across
+// retries, so receivers can use it to drop duplicates.
+type WebhookPayload struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// CreateWebhook stores a new webhook for the user. Its secret, used to sign
+// deliveries, is returned along with it and can't be retrieved later.
+func (ws *WebhookService) CreateWebhook(
+	ctx context.Context,
+	userID uuid.UUID,
+	url string,
+	eventTypes []string,
+) (pg.Webhook, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return pg.Webhook{}, err
+	}
+
+	return ws.queries.CreateWebhook(ctx, pg.CreateWebhookParams{
+		UserID:     userID,
+		Url:        url,
+		Secret:     WebhookSecretPrefix + base64.RawURLEncoding.EncodeToString(b),
+		EventTypes: eventTypes,
+	})
+}
+
+func (ws *WebhookService) ListWebhooks(ctx context.Context, userID uuid.UUID) ([]pg.ListWebhooksByUserIDRow, error) {
+	webhooks, err := ws.queries.ListWebhooksByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if webhooks == nil {
+		webhooks = []pg.ListWebhooksByUserIDRow{}
+	}
+
+	return webhooks, nil
+}
+
+func (ws *WebhookService) DeleteWebhook(ctx context.Context, userID, webhookID uuid.UUID) error {
+	rows, err := ws.queries.DeleteUserWebhook(ctx, pg.DeleteUserWebhookParams{
+		ID:     webhookID,
+		UserID: userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	if rows == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// ListDeliveries returns the latest WebhookDeliveryLogSize deliveries of a
+// webhook of the user.
+func (ws *WebhookService) ListDeliveries(ctx context.Context, userID, webhookID uuid.UUID) ([]pg.ListWebhookDeliveriesRow, error) {
+	if _, err := ws.userWebhook(ctx, userID, webhookID); err != nil {
+		return nil, err
+	}
+
+	deliveries, err := ws.queries.ListWebhookDeliveries(ctx, pg.ListWebhookDeliveriesParams{
+		WebhookID: webhookID,
+		Limit:     WebhookDeliveryLogSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if deliveries == nil {
+		deliveries = []pg.ListWebhookDeliveriesRow{}
+	}
+
+	return deliveries, nil
+}
+
+// SendTestEvent queues a webhook.test event for a webhook of the user,
+// whatever event types it subscribed to.
+func (ws *WebhookService) SendTestEvent(ctx context.Context, userID, webhookID uuid.UUID) (pg.WebhookDelivery, error) {
+	webhook, err := ws.userWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return pg.WebhookDelivery{}, err
+	}
+
+	payload, err := json.Marshal(map[string]any{
+		"webhook_id": webhook.ID,
+		"message":    "this is a test event",
+	})
+	if err != nil {
+		return pg.WebhookDelivery{}, err
+	}
+
+	return ws.queries.CreateWebhookDelivery(ctx, pg.CreateWebhookDeliveryParams{
+		WebhookID: webhook.ID,
+		EventID:   uuid.New(),
+		Topic:     TopicWebhookTest,
+		Payload:   payload,
+	})
+}
+
+func (ws *WebhookService) userWebhook(ctx context.Context, userID, webhookID uuid.UUID) (pg.Webhook, error) {
+	webhook, err := ws.queries.GetUserWebhook(ctx, pg.GetUserWebhookParams{
+		ID:     webhookID,
+		UserID: userID,
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return pg.Webhook{}, ErrWebhookNotFound
+		}
+
+		return pg.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (ws *WebhookService) Name() string {
+	return "webhooks"
+}
+
+// Deliver queues event for the webhooks of the users it is about that
+// subscribed to its topic. Deliveries are keyed on the event ID, so an event
+// delivered twice by the outbox is only sent once.
+func (ws *WebhookService) Deliver(ctx context.Context, event pg.OutboxEvent) error {
+	recipients, err := eventRecipients(event.Payload)
+	if err != nil || len(recipients) == 0 {
+		return err
+	}
+
+	webhooks, err := ws.queries.ListWebhooksForEvent(ctx, pg.ListWebhooksForEventParams{
+		UserIds: recipients,
+		Topic:   event.Topic,
+	})
+	if err != nil {
+		return err
+	}
+
+	payload, err := recipientPayload(event.Payload)
+	if err != nil {
+		return err
+	}
+
+	for _, webhook := range webhooks {
+		_, err := ws.queries.CreateWebhookDelivery(ctx, pg.CreateWebhookDeliveryParams{
+			WebhookID: webhook.ID,
+			EventID:   event.ID,
+			Topic:     event.Topic,
+			Payload:   payload,
+		})
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// eventRecipients returns the users an outbox event is about: the bidder and
+// seller of a bid, the outbid user, the seller, bidders and winner of an
+// auction, or the parties of an order.
+func eventRecipients(payload []byte) ([]uuid.UUID, error) {
+	var users struct {
+		BidderID  *uuid.UUID  `json:"bidder_id"`
+		UserID    *uuid.UUID  `json:"user_id"`
+		SellerID  *uuid.UUID  `json:"seller_id"`
+		WinnerID  *uuid.UUID  `json:"winner_id"`
+		BuyerID   *uuid.UUID  `json:"buyer_id"`
+		BidderIDs []uuid.UUID `json:"bidder_ids"`
+	}
+	if err := json.Unmarshal(payload, &users); err != nil {
+		return nil, err
+	}
+
+	recipients := users.BidderIDs
+	for _, id := range []*uuid.UUID{users.BidderID, users.UserID, users.SellerID, users.WinnerID, users.BuyerID} {
+		if id != nil {
+			recipients = append(recipients, *id)
+		}
+	}
+
+	return recipients, nil
+}
+
+// Run sends the pending deliveries until ctx is done. Like the outbox,
+// deliveries are claimed for WebhookClaimTimeout so several instances can
+// run it at once.
+func (ws *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(WebhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			sent, err := ws.sendPending(ctx)
+			if err != nil {
+				slog.Error("Failed to send webhooks", "Error", err)
+				break
+			}
+
+			if sent < WebhookBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sendPending sends a batch of deliveries concurrently and returns its size.
+func (ws *WebhookService) sendPending(ctx context.Context) (int, error) {
+	deliveries, err := ws.queries.ClaimWebhookDeliveries(ctx, pg.ClaimWebhookDeliveriesParams{
+		LeaseUntil:    time.Now().Add(WebhookClaimTimeout),
+		MaxDeliveries: WebhookBatchSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ws.send(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+// send makes a delivery and records its outcome. Any 2xx response counts as
+// a success.
+func (ws *WebhookService) send(ctx context.Context, delivery pg.ClaimWebhookDeliveriesRow) {
+	status, err := ws.post(ctx, delivery)
+	if err == nil {
+		err = ws.queries.MarkWebhookDeliverySucceeded(ctx, pg.MarkWebhookDeliverySucceededParams{
+			ID:             delivery.ID,
+			ResponseStatus: int32(status),
+		})
+		if err != nil {
+			slog.Error("Failed to record webhook delivery", "ID", delivery.ID, "Error", err)
+		}
+		return
+	}
+
+	attempts := delivery.Attempts + 1
+	wait, giveUp := webhookRetry(attempts)
+	slog.Warn("Failed to deliver webhook", "ID", delivery.ID, "Topic", delivery.Topic, "Attempts", attempts, "GiveUp", giveUp, "Error", err)
+
+	err = ws.queries.MarkWebhookDeliveryFailed(ctx, pg.MarkWebhookDeliveryFailedParams{
+		ResponseStatus: int32(status),
+		LastError:      err.Error(),
+		RetryAt:        time.Now().Add(wait),
+		GiveUp:         giveUp,
+		ID:             delivery.ID,
+	})
+	if err != nil {
+		slog.Error("Failed to record webhook delivery", "ID", delivery.ID, "Error", err)
+	}
+}
+
+// webhookRetry returns how long to wait before retrying a delivery that
+// failed attempts times, and whether to give up on it instead.
+func webhookRetry(attempts int32) (time.Duration, bool) {
+	return backoff(attempts, WebhookBaseBackoff, WebhookMaxBackoff), attempts >= WebhookMaxAttempts
+}
+
+// post sends a delivery and returns the response status, 0 if there was
+// none.
+func (ws *WebhookService) post(ctx context.Context, delivery pg.ClaimWebhookDeliveriesRow) (int, error) {
+	body, err := json.Marshal(WebhookPayload{
+		ID:        delivery.EventID,
+		Type:      delivery.Topic,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.Url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "bid-webhooks")
+	req.Header.Set("X-Bid-Delivery", delivery.ID.String())
+	req.Header.Set("X-Bid-Event", delivery.Topic)
+	req.Header.Set("X-Bid-Timestamp", timestamp)
+	req.Header.Set("X-Bid-Signature", "sha256="+SignWebhook(delivery.Secret, timestamp, body))
+
+	res, err := ws.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected response status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
+
+// SignWebhook returns the hex encoded HMAC-SHA256 of "timestamp.body" keyed
+// with the webhook secret, as sent in the X-Bid-Signature header.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
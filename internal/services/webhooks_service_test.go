@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/store/pg"
+)
+
+func TestIsPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr   string
+		public bool
+	}{
+		{addr: "93.184.216.34", public: true},
+		{addr: "2606:2800:220:1:248:1893:25c8:1946", public: true},
+		{addr: "127.0.0.1"},
+		{addr: "::1"},
+		{addr: "10.0.0.8"},
+		{addr: "172.16.4.1"},
+		{addr: "192.168.1.1"},
+		{addr: "169.254.169.254"},
+		{addr: "fe80::1"},
+		{addr: "fd00::1"},
+		{addr: "0.0.0.0"},
+		{addr: "::"},
+		{addr: "100.64.0.1"},
+		{addr: "224.0.0.1"},
+		{addr: "::ffff:127.0.0.1"},
+		{addr: "::ffff:169.254.169.254"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.addr, func(t *testing.T) {
+			if got := IsPublicAddr(netip.MustParseAddr(test.addr)); got != test.public {
+				t.Errorf("IsPublicAddr(%s) = %v, want %v", test.addr, got, test.public)
+			}
+		})
+	}
+}
+
+func TestWebhookClientRefusesInternalAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the webhook client reached a loopback server")
+	}))
+	defer server.Close()
+
+	urls := []string{
+		server.URL,
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]:9/",
+		"http://10.0.0.1:9/",
+	}
+
+	client := newWebhookClient()
+	for _, url := range urls {
+		t.Run(url, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+			defer cancel()
+
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := client.Do(req)
+			if err == nil {
+				res.Body.Close()
+			}
+
+			if !errors.Is(err, ErrForbiddenWebhookAddress) {
+				t.Errorf("error = %v, want %v", err, ErrForbiddenWebhookAddress)
+			}
+		})
+	}
+}
+
+func TestWebhookClientDoesNotFollowRedirects(t *testing.T) {
+	var followed atomic.Bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hook", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/internal", http.StatusFound)
+	})
+	mux.HandleFunc("/internal", func(w http.ResponseWriter, r *http.Request) {
+		followed.Store(true)
+	})
+
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// The test server listens on loopback, which the dialer of the webhook
+	// client refuses, so only its redirect policy is used here.
+	client := newWebhookClient()
+	client.Transport = server.Client().Transport
+	ws := WebhookService{client: client}
+
+	status, err := ws.post(context.Background(), testDelivery(server.URL+"/hook"))
+	if err == nil {
+		t.Error("a redirect was taken for a successful delivery")
+	}
+
+	if status != http.StatusFound {
+		t.Errorf("status = %d, want %d", status, http.StatusFound)
+	}
+
+	if followed.Load() {
+		t.Error("the redirect was followed")
+	}
+}
+
+func TestWebhookPostSignsDelivery(t *testing.T) {
+	delivery := testDelivery("")
+
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header, body: body}
+	}))
+	defer server.Close()
+
+	delivery.Url = server.URL
+	ws := WebhookService{client: server.Client()}
+	if _, err := ws.post(context.Background(), delivery); err != nil {
+		t.Fatalf("post: %v", err)
+	}
+
+	req := <-requests
+	timestamp := req.header.Get("X-Bid-Timestamp")
+	if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+		t.Errorf("timestamp = %q, want unix seconds", timestamp)
+	}
+
+	mac := hmac.New(sha256.New, []byte(delivery.Secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(req.body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := req.header.Get("X-Bid-Signature"); got != want {
+		t.Errorf("signature = %q, want %q", got, want)
+	}
+
+	headers := map[string]string{
+		"X-Bid-Delivery": delivery.ID.String(),
+		"X-Bid-Event":    delivery.Topic,
+		"Content-Type":   "application/json",
+	}
+	for name, want := range headers {
+		if got := req.header.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(req.body, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+
+	if payload.ID != delivery.EventID || payload.Type != delivery.Topic {
+		t.Errorf("payload = %+v, want event %s of %s", payload, delivery.EventID, delivery.Topic)
+	}
+}
+
+func TestWebhookPostStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		ok     bool
+	}{
+		{status: http.StatusOK, ok: true},
+		{status: http.StatusNoContent, ok: true},
+		{status: http.StatusNotFound},
+		{status: http.StatusInternalServerError},
+		{status: http.StatusServiceUnavailable},
+	}
+
+	for _, test := range tests {
+		t.Run(strconv.Itoa(test.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.status)
+			}))
+			defer server.Close()
+
+			ws := WebhookService{client: server.Client()}
+			status, err := ws.post(context.Background(), testDelivery(server.URL))
+			if status != test.status {
+				t.Errorf("status = %d, want %d", status, test.status)
+			}
+
+			if (err == nil) != test.ok {
+				t.Errorf("error = %v, want success %v", err, test.ok)
+			}
+		})
+	}
+}
+
+func TestWebhookRetry(t *testing.T) {
+	tests := []struct {
+		attempts int32
+		wait     time.Duration
+		giveUp   bool
+	}{
+		{attempts: 1, wait: WebhookBaseBackoff},
+		{attempts: 2, wait: 2 * WebhookBaseBackoff},
+		{attempts: 3, wait: 4 * WebhookBaseBackoff},
+		{attempts: 7, wait: 64 * WebhookBaseBackoff},
+		{attempts: WebhookMaxAttempts, wait: 128 * WebhookBaseBackoff, giveUp: true},
+		{attempts: 20, wait: WebhookMaxBackoff, giveUp: true},
+	}
+
+	for _, test := range tests {
+		t.Run(strconv.Itoa(int(test.attempts)), func(t *testing.T) {
+			wait, giveUp := webhookRetry(test.attempts)
+			if wait != test.wait || giveUp != test.giveUp {
+				t.Errorf("webhookRetry(%d) = %s, %v, want %s, %v", test.attempts, wait, giveUp, test.wait, test.giveUp)
+			}
+		})
+	}
+}
+
+func testDelivery(url string) pg.ClaimWebhookDeliveriesRow {
+	return pg.ClaimWebhookDeliveriesRow{
+		ID:        uuid.New(),
+		EventID:   uuid.New(),
+		Topic:     TopicBidPlaced,
+		Payload:   []byte(`{"bid_id":"1"}`),
+		CreatedAt: time.Now(),
+		Url:       url,
+		Secret:    WebhookSecretPrefix + "secret",
+	}
+}
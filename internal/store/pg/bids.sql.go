@@ -87,3 +87,28 @@ func (q *Queries) GetHighestBidByProductID(ctx context.Context, productID uuid.U
 	)
 	return i, err
 }
+
+const listBidderIDsByProductID = `-- name: ListBidderIDsByProductID :many
+SELECT DISTINCT bidder_id FROM bids
+WHERE product_id = $1
+`
+
+func (q *Queries) ListBidderIDsByProductID(ctx context.Context, productID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, listBidderIDsByProductID, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var bidder_id uuid.UUID
+		if err := rows.Scan(&bidder_id); err != nil {
+			return nil, err
+		}
+		items = append(items, bidder_id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,12 @@
+-- Write your migrate up statements here
+ALTER TABLE users
+  ADD COLUMN role TEXT NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'moderator', 'admin')),
+  ADD COLUMN suspended_at TIMESTAMPTZ;
+
+---- create above / drop below ----
+ALTER TABLE users
+  DROP COLUMN IF EXISTS suspended_at,
+  DROP COLUMN IF EXISTS role;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,19 @@
+-- Write your migrate up statements here
+CREATE TABLE IF NOT EXISTS api_tokens (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  name TEXT NOT NULL,
+  token_hash BYTEA UNIQUE NOT NULL,
+  scopes TEXT[] NOT NULL,
+  last_used_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX api_tokens_user_id_idx ON api_tokens (user_id);
+---- create above / drop below ----
+DROP INDEX IF EXISTS api_tokens_user_id_idx;
+DROP TABLE IF EXISTS api_tokens;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
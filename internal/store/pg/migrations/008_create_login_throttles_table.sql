@@ -0,0 +1,25 @@
+-- Write your migrate up statements here
+CREATE TABLE IF NOT EXISTS login_throttles (
+  key TEXT PRIMARY KEY,
+  failures INT NOT NULL DEFAULT 0,
+  last_failure_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  locked_until TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS login_lockouts (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  key TEXT NOT NULL,
+  failures INT NOT NULL,
+  ip_address TEXT NOT NULL,
+  locked_until TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX login_lockouts_key_idx ON login_lockouts (key);
+---- create above / drop below ----
+DROP INDEX IF EXISTS login_lockouts_key_idx;
+DROP TABLE IF EXISTS login_lockouts;
+DROP TABLE IF EXISTS login_throttles;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,15 @@
+-- Write your migrate up statements here
+CREATE TABLE IF NOT EXISTS auction_events (
+  product_id UUID NOT NULL REFERENCES products (id),
+  seq BIGINT NOT NULL,
+  type TEXT NOT NULL,
+  data JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (product_id, seq)
+);
+---- create above / drop below ----
+DROP TABLE IF EXISTS auction_events;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
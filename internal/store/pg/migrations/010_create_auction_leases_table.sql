@@ -0,0 +1,11 @@
+-- Write your migrate up statements here
+CREATE TABLE IF NOT EXISTS auction_leases (
+  product_id UUID PRIMARY KEY REFERENCES products (id),
+  instance_id UUID NOT NULL,
+  expires_at TIMESTAMPTZ NOT NULL
+);
+---- create above / drop below ----
+DROP TABLE IF EXISTS auction_leases;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,22 @@
+-- Write your migrate up statements here
+CREATE TABLE IF NOT EXISTS outbox_events (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  topic TEXT NOT NULL,
+  aggregate_id UUID NOT NULL,
+  payload JSONB NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  last_error TEXT NOT NULL DEFAULT '',
+  available_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  dispatched_at TIMESTAMPTZ,
+  failed_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX outbox_events_pending_idx ON outbox_events (available_at)
+  WHERE dispatched_at IS NULL AND failed_at IS NULL;
+---- create above / drop below ----
+DROP INDEX IF EXISTS outbox_events_pending_idx;
+DROP TABLE IF EXISTS outbox_events;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
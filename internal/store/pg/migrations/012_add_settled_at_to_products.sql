@@ -0,0 +1,10 @@
+-- Write your migrate up statements here
+ALTER TABLE products
+  ADD COLUMN settled_at TIMESTAMPTZ;
+
+---- create above / drop below ----
+ALTER TABLE products
+  DROP COLUMN IF EXISTS settled_at;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
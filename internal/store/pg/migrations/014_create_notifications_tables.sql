@@ -0,0 +1,33 @@
+-- Write your migrate up statements here
+CREATE TABLE IF NOT EXISTS notifications (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  kind TEXT NOT NULL,
+  event_id UUID NOT NULL,
+  title TEXT NOT NULL,
+  body TEXT NOT NULL,
+  data JSONB NOT NULL DEFAULT '{}',
+  read_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  UNIQUE (user_id, event_id)
+);
+
+CREATE INDEX notifications_user_id_created_at_idx ON notifications (user_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS notification_preferences (
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  kind TEXT NOT NULL,
+  in_app BOOLEAN NOT NULL,
+  email BOOLEAN NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (user_id, kind)
+);
+---- create above / drop below ----
+DROP TABLE IF EXISTS notification_preferences;
+DROP INDEX IF EXISTS notifications_user_id_created_at_idx;
+DROP TABLE IF EXISTS notifications;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
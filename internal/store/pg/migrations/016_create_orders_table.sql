@@ -0,0 +1,24 @@
+-- Write your migrate up statements here
+CREATE TABLE IF NOT EXISTS orders (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  product_id UUID NOT NULL UNIQUE REFERENCES products (id),
+  seller_id UUID NOT NULL REFERENCES users (id),
+  buyer_id UUID NOT NULL REFERENCES users (id),
+  bid_id UUID NOT NULL REFERENCES bids (id),
+  final_price FLOAT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'awaiting_payment'
+    CHECK (status IN ('awaiting_payment', 'paid', 'shipped', 'delivered', 'cancelled')),
+  tracking_number TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX orders_seller_id_idx ON orders (seller_id, created_at DESC);
+CREATE INDEX orders_buyer_id_idx ON orders (buyer_id, created_at DESC);
+---- create above / drop below ----
+DROP INDEX IF EXISTS orders_buyer_id_idx;
+DROP INDEX IF EXISTS orders_seller_id_idx;
+DROP TABLE IF EXISTS orders;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,43 @@
+-- Write your migrate up statements here
+CREATE TABLE IF NOT EXISTS payments (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  order_id UUID NOT NULL REFERENCES orders (id) ON DELETE CASCADE,
+  provider TEXT NOT NULL,
+  intent_id TEXT NOT NULL DEFAULT '',
+  amount FLOAT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (
+    status IN ('pending', 'requires_action', 'requires_capture', 'succeeded', 'failed', 'refunded')
+  ),
+  next_action_url TEXT NOT NULL DEFAULT '',
+  failure_reason TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX payments_order_id_idx ON payments (order_id, created_at DESC);
+
+CREATE UNIQUE INDEX payments_intent_id_idx ON payments (provider, intent_id)
+  WHERE intent_id <> '';
+
+-- An order has at most one payment going on or succeeded at a time.
+CREATE UNIQUE INDEX payments_open_order_id_idx ON payments (order_id)
+  WHERE status IN ('pending', 'requires_action', 'requires_capture', 'succeeded');
+
+CREATE TABLE IF NOT EXISTS payment_events (
+  provider TEXT NOT NULL,
+  event_id TEXT NOT NULL,
+  type TEXT NOT NULL,
+  payload JSONB NOT NULL,
+  received_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (provider, event_id)
+);
+---- create above / drop below ----
+DROP TABLE IF EXISTS payment_events;
+DROP INDEX IF EXISTS payments_open_order_id_idx;
+DROP INDEX IF EXISTS payments_intent_id_idx;
+DROP INDEX IF EXISTS payments_order_id_idx;
+DROP TABLE IF EXISTS payments;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,64 @@
+-- Write your migrate up statements here
+ALTER TABLE orders
+  ADD COLUMN payment_due_at TIMESTAMPTZ NOT NULL DEFAULT NOW() + INTERVAL '72 hours',
+  DROP CONSTRAINT orders_status_check,
+  ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('awaiting_payment', 'paid', 'shipped', 'delivered', 'cancelled', 'defaulted')),
+  DROP CONSTRAINT orders_product_id_key;
+
+ALTER TABLE orders
+  ALTER COLUMN payment_due_at DROP DEFAULT;
+
+-- A product is sold through one order at a time: cancelled and defaulted
+-- orders make way for second-chance offers.
+CREATE UNIQUE INDEX orders_open_product_id_idx ON orders (product_id)
+  WHERE status NOT IN ('cancelled', 'defaulted');
+
+CREATE INDEX orders_payment_due_at_idx ON orders (payment_due_at)
+  WHERE status = 'awaiting_payment';
+
+CREATE TABLE IF NOT EXISTS second_chance_offers (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  order_id UUID NOT NULL REFERENCES orders (id),
+  product_id UUID NOT NULL REFERENCES products (id),
+  seller_id UUID NOT NULL REFERENCES users (id),
+  bidder_id UUID NOT NULL REFERENCES users (id),
+  bid_id UUID NOT NULL REFERENCES bids (id),
+  amount FLOAT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'accepted', 'declined', 'expired')),
+  expires_at TIMESTAMPTZ NOT NULL,
+  responded_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  UNIQUE (product_id, bidder_id)
+);
+
+CREATE UNIQUE INDEX second_chance_offers_pending_product_id_idx ON second_chance_offers (product_id)
+  WHERE status = 'pending';
+
+CREATE INDEX second_chance_offers_expires_at_idx ON second_chance_offers (expires_at)
+  WHERE status = 'pending';
+
+CREATE INDEX second_chance_offers_seller_id_idx ON second_chance_offers (seller_id, created_at DESC);
+CREATE INDEX second_chance_offers_bidder_id_idx ON second_chance_offers (bidder_id, created_at DESC);
+---- create above / drop below ----
+DROP INDEX IF EXISTS second_chance_offers_bidder_id_idx;
+DROP INDEX IF EXISTS second_chance_offers_seller_id_idx;
+DROP INDEX IF EXISTS second_chance_offers_expires_at_idx;
+DROP INDEX IF EXISTS second_chance_offers_pending_product_id_idx;
+DROP TABLE IF EXISTS second_chance_offers;
+
+DROP INDEX IF EXISTS orders_payment_due_at_idx;
+DROP INDEX IF EXISTS orders_open_product_id_idx;
+
+UPDATE orders SET status = 'cancelled' WHERE status = 'defaulted';
+
+ALTER TABLE orders
+  DROP CONSTRAINT orders_status_check,
+  ADD CONSTRAINT orders_status_check
+    CHECK (status IN ('awaiting_payment', 'paid', 'shipped', 'delivered', 'cancelled')),
+  ADD CONSTRAINT orders_product_id_key UNIQUE (product_id),
+  DROP COLUMN IF EXISTS payment_due_at;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
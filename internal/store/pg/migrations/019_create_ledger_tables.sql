@@ -0,0 +1,60 @@
+-- Write your migrate up statements here
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  kind TEXT NOT NULL CHECK (kind IN ('platform_cash', 'platform_fees', 'seller_pending', 'seller_available')),
+  -- Platform accounts have no user.
+  user_id UUID REFERENCES users (id),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  UNIQUE NULLS NOT DISTINCT (kind, user_id)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_journals (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  kind TEXT NOT NULL CHECK (kind IN ('payment', 'refund', 'earning', 'payout')),
+  reference_id UUID NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+  UNIQUE (kind, reference_id)
+);
+
+-- Amounts are in cents: debits are positive and credits negative, so the
+-- postings of a journal sum to zero.
+CREATE TABLE IF NOT EXISTS ledger_postings (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  journal_id UUID NOT NULL REFERENCES ledger_journals (id),
+  account_id UUID NOT NULL REFERENCES ledger_accounts (id),
+  amount BIGINT NOT NULL CHECK (amount <> 0),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX ledger_postings_journal_id_idx ON ledger_postings (journal_id);
+CREATE INDEX ledger_postings_account_id_idx ON ledger_postings (account_id, created_at DESC);
+
+CREATE FUNCTION ledger_check_journal_balance() RETURNS TRIGGER AS $$
+BEGIN
+  IF (SELECT SUM(amount) FROM ledger_postings WHERE journal_id = NEW.journal_id) <> 0 THEN
+    RAISE EXCEPTION 'ledger journal % does not balance', NEW.journal_id;
+  END IF;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Checked at commit, once every posting of the journal is in.
+CREATE CONSTRAINT TRIGGER ledger_postings_balance_check
+  AFTER INSERT ON ledger_postings
+  DEFERRABLE INITIALLY DEFERRED
+  FOR EACH ROW EXECUTE FUNCTION ledger_check_journal_balance();
+---- create above / drop below ----
+DROP TRIGGER IF EXISTS ledger_postings_balance_check ON ledger_postings;
+DROP FUNCTION IF EXISTS ledger_check_journal_balance;
+DROP INDEX IF EXISTS ledger_postings_account_id_idx;
+DROP INDEX IF EXISTS ledger_postings_journal_id_idx;
+DROP TABLE IF EXISTS ledger_postings;
+DROP TABLE IF EXISTS ledger_journals;
+DROP TABLE IF EXISTS ledger_accounts;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,48 @@
+-- Write your migrate up statements here
+ALTER TABLE products ADD COLUMN category TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE IF NOT EXISTS fee_rules (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  -- Rules without a category apply to every category.
+  category TEXT,
+  -- Prices from min_price up to, but excluding, max_price; rules without
+  -- max_price have no upper bound.
+  min_price FLOAT NOT NULL DEFAULT 0 CHECK (min_price >= 0),
+  max_price FLOAT CHECK (max_price > min_price),
+  kind TEXT NOT NULL CHECK (kind IN ('percentage', 'fixed', 'capped', 'tiered')),
+  rate FLOAT NOT NULL DEFAULT 0 CHECK (rate >= 0 AND rate <= 1),
+  amount FLOAT NOT NULL DEFAULT 0 CHECK (amount >= 0),
+  cap FLOAT NOT NULL DEFAULT 0 CHECK (cap >= 0),
+  tiers JSONB NOT NULL DEFAULT '[]',
+  priority INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX fee_rules_category_idx ON fee_rules (category, min_price);
+
+-- Orders settled before fee rules paid the flat 5% fee.
+ALTER TABLE orders
+  ADD COLUMN fee FLOAT NOT NULL DEFAULT 0,
+  ADD COLUMN net_proceeds FLOAT NOT NULL DEFAULT 0,
+  ADD COLUMN fee_rule_id UUID REFERENCES fee_rules (id) ON DELETE SET NULL;
+
+UPDATE orders SET fee = ROUND((final_price * 0.05)::NUMERIC, 2);
+UPDATE orders SET net_proceeds = final_price - fee;
+
+ALTER TABLE orders
+  ALTER COLUMN fee DROP DEFAULT,
+  ALTER COLUMN net_proceeds DROP DEFAULT;
+---- create above / drop below ----
+ALTER TABLE orders
+  DROP COLUMN IF EXISTS fee_rule_id,
+  DROP COLUMN IF EXISTS net_proceeds,
+  DROP COLUMN IF EXISTS fee;
+
+DROP INDEX IF EXISTS fee_rules_category_idx;
+DROP TABLE IF EXISTS fee_rules;
+
+ALTER TABLE products DROP COLUMN IF EXISTS category;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -0,0 +1,11 @@
+-- Write your migrate up statements here
+-- Users without a locale get emails in the default locale.
+ALTER TABLE users
+  ADD COLUMN locale TEXT NOT NULL DEFAULT '';
+
+---- create above / drop below ----
+ALTER TABLE users
+  DROP COLUMN IF EXISTS locale;
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
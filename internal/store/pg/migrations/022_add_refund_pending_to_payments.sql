@@ -0,0 +1,25 @@
+-- Write your migrate up statements here
+-- Payments that succeed for an order that no longer awaits payment are
+-- refund_pending until the provider refunds them.
+ALTER TABLE payments
+  DROP CONSTRAINT payments_status_check,
+  ADD CONSTRAINT payments_status_check CHECK (
+    status IN ('pending', 'requires_action', 'requires_capture', 'succeeded', 'refund_pending', 'failed', 'refunded')
+  );
+
+CREATE INDEX payments_refund_pending_idx ON payments (updated_at)
+  WHERE status = 'refund_pending';
+
+---- create above / drop below ----
+DROP INDEX IF EXISTS payments_refund_pending_idx;
+
+UPDATE payments SET status = 'succeeded' WHERE status = 'refund_pending';
+
+ALTER TABLE payments
+  DROP CONSTRAINT payments_status_check,
+  ADD CONSTRAINT payments_status_check CHECK (
+    status IN ('pending', 'requires_action', 'requires_capture', 'succeeded', 'failed', 'refunded')
+  );
+
+-- Write your migrate down statements here. If this migration is irreversible
+-- Then delete the separator line above.
@@ -5,11 +5,37 @@
 package pg
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type ApiToken struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Name       string     `json:"name"`
+	TokenHash  []byte     `json:"token_hash"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type AuctionEvent struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Seq       int64     `json:"seq"`
+	Type      string    `json:"type"`
+	Data      []byte    `json:"data"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type AuctionLease struct {
+	ProductID  uuid.UUID `json:"product_id"`
+	InstanceID uuid.UUID `json:"instance_id"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
 type Bid struct {
 	ID        uuid.UUID `json:"id"`
 	ProductID uuid.UUID `json:"product_id"`
@@ -18,16 +44,158 @@ type Bid struct {
 	CreatedAt time.Time `json:"created_at"`
 }
 
-type Product struct {
+type FeeRule struct {
+	ID        uuid.UUID       `json:"id"`
+	Category  *string         `json:"category"`
+	MinPrice  float64         `json:"min_price"`
+	MaxPrice  *float64        `json:"max_price"`
+	Kind      string          `json:"kind"`
+	Rate      float64         `json:"rate"`
+	Amount    float64         `json:"amount"`
+	Cap       float64         `json:"cap"`
+	Tiers     json.RawMessage `json:"tiers"`
+	Priority  int32           `json:"priority"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+type LedgerAccount struct {
+	ID        uuid.UUID  `json:"id"`
+	Kind      string     `json:"kind"`
+	UserID    *uuid.UUID `json:"user_id"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type LedgerJournal struct {
 	ID          uuid.UUID `json:"id"`
-	SellerID    uuid.UUID `json:"seller_id"`
-	Name        string    `json:"name"`
+	Kind        string    `json:"kind"`
+	ReferenceID uuid.UUID `json:"reference_id"`
 	Description string    `json:"description"`
-	BasePrice   float64   `json:"base_price"`
-	AuctionEnd  time.Time `json:"auction_end"`
-	IsSold      bool      `json:"is_sold"`
 	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type LedgerPosting struct {
+	ID        uuid.UUID `json:"id"`
+	JournalID uuid.UUID `json:"journal_id"`
+	AccountID uuid.UUID `json:"account_id"`
+	Amount    int64     `json:"amount"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type LoginLockout struct {
+	ID          uuid.UUID `json:"id"`
+	Key         string    `json:"key"`
+	Failures    int32     `json:"failures"`
+	IpAddress   string    `json:"ip_address"`
+	LockedUntil time.Time `json:"locked_until"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type LoginThrottle struct {
+	Key           string     `json:"key"`
+	Failures      int32      `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+}
+
+type Notification struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Kind      string     `json:"kind"`
+	EventID   uuid.UUID  `json:"event_id"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	Data      []byte     `json:"data"`
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+type NotificationPreference struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Kind      string    `json:"kind"`
+	InApp     bool      `json:"in_app"`
+	Email     bool      `json:"email"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Order struct {
+	ID             uuid.UUID  `json:"id"`
+	ProductID      uuid.UUID  `json:"product_id"`
+	SellerID       uuid.UUID  `json:"seller_id"`
+	BuyerID        uuid.UUID  `json:"buyer_id"`
+	BidID          uuid.UUID  `json:"bid_id"`
+	FinalPrice     float64    `json:"final_price"`
+	Status         string     `json:"status"`
+	TrackingNumber string     `json:"tracking_number"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	PaymentDueAt   time.Time  `json:"payment_due_at"`
+	Fee            float64    `json:"fee"`
+	NetProceeds    float64    `json:"net_proceeds"`
+	FeeRuleID      *uuid.UUID `json:"fee_rule_id"`
+}
+
+type OutboxEvent struct {
+	ID           uuid.UUID  `json:"id"`
+	Topic        string     `json:"topic"`
+	AggregateID  uuid.UUID  `json:"aggregate_id"`
+	Payload      []byte     `json:"payload"`
+	Attempts     int32      `json:"attempts"`
+	LastError    string     `json:"last_error"`
+	AvailableAt  time.Time  `json:"available_at"`
+	DispatchedAt *time.Time `json:"dispatched_at"`
+	FailedAt     *time.Time `json:"failed_at"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+type Payment struct {
+	ID            uuid.UUID `json:"id"`
+	OrderID       uuid.UUID `json:"order_id"`
+	Provider      string    `json:"provider"`
+	IntentID      string    `json:"intent_id"`
+	Amount        float64   `json:"amount"`
+	Status        string    `json:"status"`
+	NextActionUrl string    `json:"next_action_url"`
+	FailureReason string    `json:"failure_reason"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type PaymentEvent struct {
+	Provider   string    `json:"provider"`
+	EventID    string    `json:"event_id"`
+	Type       string    `json:"type"`
+	Payload    []byte    `json:"payload"`
+	ReceivedAt time.Time `json:"received_at"`
+}
+
+type Product struct {
+	ID               uuid.UUID  `json:"id"`
+	SellerID         uuid.UUID  `json:"seller_id"`
+	Name             string     `json:"name"`
+	Description      string     `json:"description"`
+	BasePrice        float64    `json:"base_price"`
+	AuctionEnd       time.Time  `json:"auction_end"`
+	IsSold           bool       `json:"is_sold"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	SettledAt        *time.Time `json:"settled_at"`
+	EndingSoonSentAt *time.Time `json:"ending_soon_sent_at"`
+	Category         string     `json:"category"`
+}
+
+type SecondChanceOffer struct {
+	ID          uuid.UUID  `json:"id"`
+	OrderID     uuid.UUID  `json:"order_id"`
+	ProductID   uuid.UUID  `json:"product_id"`
+	SellerID    uuid.UUID  `json:"seller_id"`
+	BidderID    uuid.UUID  `json:"bidder_id"`
+	BidID       uuid.UUID  `json:"bid_id"`
+	Amount      float64    `json:"amount"`
+	Status      string     `json:"status"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RespondedAt *time.Time `json:"responded_at"`
+	CreatedAt   time.Time  `json:"created_at"`
 }
 
 type Session struct {
@@ -37,11 +205,48 @@ type Session struct {
 }
 
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	Username     string    `json:"username"`
-	Email        string    `json:"email"`
-	PasswordHash []byte    `json:"password_hash"`
-	Bio          string    `json:"bio"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           uuid.UUID  `json:"id"`
+	Username     string     `json:"username"`
+	Email        string     `json:"email"`
+	PasswordHash []byte     `json:"password_hash"`
+	Bio          string     `json:"bio"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Role         string     `json:"role"`
+	SuspendedAt  *time.Time `json:"suspended_at"`
+	Locale       string     `json:"locale"`
+}
+
+type UserSession struct {
+	ID         uuid.UUID `json:"id"`
+	Token      string    `json:"token"`
+	UserID     uuid.UUID `json:"user_id"`
+	IpAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+type Webhook struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"user_id"`
+	Url        string    `json:"url"`
+	Secret     string    `json:"secret"`
+	EventTypes []string  `json:"event_types"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID             uuid.UUID  `json:"id"`
+	WebhookID      uuid.UUID  `json:"webhook_id"`
+	EventID        uuid.UUID  `json:"event_id"`
+	Topic          string     `json:"topic"`
+	Payload        []byte     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int32      `json:"attempts"`
+	ResponseStatus int32      `json:"response_status"`
+	LastError      string     `json:"last_error"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	CreatedAt      time.Time  `json:"created_at"`
 }
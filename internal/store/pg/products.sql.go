@@ -12,14 +12,61 @@ import (
 	"github.com/google/uuid"
 )
 
+const claimEndingSoonProducts = `-- name: ClaimEndingSoonProducts :many
+UPDATE products
+SET ending_soon_sent_at = NOW()
+WHERE id IN (
+  SELECT id FROM products
+  WHERE ending_soon_sent_at IS NULL
+    AND auction_end > NOW()
+    AND auction_end <= $1
+  ORDER BY auction_end
+  LIMIT $2
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, seller_id, auction_end
+`
+
+type ClaimEndingSoonProductsParams struct {
+	EndsBefore  time.Time `json:"ends_before"`
+	MaxProducts int32     `json:"max_products"`
+}
+
+type ClaimEndingSoonProductsRow struct {
+	ID         uuid.UUID `json:"id"`
+	SellerID   uuid.UUID `json:"seller_id"`
+	AuctionEnd time.Time `json:"auction_end"`
+}
+
+func (q *Queries) ClaimEndingSoonProducts(ctx context.Context, arg ClaimEndingSoonProductsParams) ([]ClaimEndingSoonProductsRow, error) {
+	rows, err := q.db.Query(ctx, claimEndingSoonProducts, arg.EndsBefore, arg.MaxProducts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClaimEndingSoonProductsRow
+	for rows.Next() {
+		var i ClaimEndingSoonProductsRow
+		if err := rows.Scan(&i.ID, &i.SellerID, &i.AuctionEnd); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const createProduct = `-- name: CreateProduct :one
 INSERT INTO products (
   seller_id,
   name,
   description,
   base_price,
-  auction_end
-) VALUES ($1, $2, $3, $4, $5) RETURNING id
+  auction_end,
+  category
+) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id
 `
 
 type CreateProductParams struct {
@@ -28,6 +75,7 @@ type CreateProductParams struct {
 	Description string    `json:"description"`
 	BasePrice   float64   `json:"base_price"`
 	AuctionEnd  time.Time `json:"auction_end"`
+	Category    string    `json:"category"`
 }
 
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (uuid.UUID, error) {
@@ -37,6 +85,7 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (u
 		arg.Description,
 		arg.BasePrice,
 		arg.AuctionEnd,
+		arg.Category,
 	)
 	var id uuid.UUID
 	err := row.Scan(&id)
@@ -44,7 +93,7 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (u
 }
 
 const getProductByID = `-- name: GetProductByID :one
-SELECT id, seller_id, name, description, base_price, auction_end, is_sold, created_at, updated_at FROM products WHERE id = $1
+SELECT id, seller_id, name, description, base_price, auction_end, is_sold, created_at, updated_at, settled_at, ending_soon_sent_at, category FROM products WHERE id = $1
 `
 
 func (q *Queries) GetProductByID(ctx context.Context, id uuid.UUID) (Product, error) {
@@ -60,6 +109,76 @@ func (q *Queries) GetProductByID(ctx context.Context, id uuid.UUID) (Product, er
 		&i.IsSold,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.SettledAt,
+		&i.EndingSoonSentAt,
+		&i.Category,
+	)
+	return i, err
+}
+
+const getProductByIDForUpdate = `-- name: GetProductByIDForUpdate :one
+SELECT id, seller_id, name, description, base_price, auction_end, is_sold, created_at, updated_at, settled_at, ending_soon_sent_at, category FROM products WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetProductByIDForUpdate(ctx context.Context, id uuid.UUID) (Product, error) {
+	row := q.db.QueryRow(ctx, getProductByIDForUpdate, id)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.SellerID,
+		&i.Name,
+		&i.Description,
+		&i.BasePrice,
+		&i.AuctionEnd,
+		&i.IsSold,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SettledAt,
+		&i.EndingSoonSentAt,
+		&i.Category,
 	)
 	return i, err
 }
+
+const listUnsettledProducts = `-- name: ListUnsettledProducts :many
+SELECT id FROM products
+WHERE settled_at IS NULL AND auction_end <= NOW()
+ORDER BY auction_end
+LIMIT $1
+`
+
+func (q *Queries) ListUnsettledProducts(ctx context.Context, limit int32) ([]uuid.UUID, error) {
+	rows, err := q.db.Query(ctx, listUnsettledProducts, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const settleProduct = `-- name: SettleProduct :exec
+UPDATE products
+SET settled_at = NOW(), is_sold = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SettleProductParams struct {
+	ID     uuid.UUID `json:"id"`
+	IsSold bool      `json:"is_sold"`
+}
+
+func (q *Queries) SettleProduct(ctx context.Context, arg SettleProductParams) error {
+	_, err := q.db.Exec(ctx, settleProduct, arg.ID, arg.IsSold)
+	return err
+}
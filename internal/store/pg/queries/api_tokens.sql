@@ -0,0 +1,21 @@
+-- name: CreateApiToken :one
+INSERT INTO api_tokens (user_id, name, token_hash, scopes)
+VALUES ($1, $2, $3, $4)
+RETURNING id, name, scopes, created_at;
+
+-- name: ListApiTokensByUserID :many
+SELECT id, name, scopes, last_used_at, created_at
+FROM api_tokens
+WHERE user_id = $1 AND revoked_at IS NULL
+ORDER BY created_at DESC;
+
+-- name: UseApiToken :one
+UPDATE api_tokens
+SET last_used_at = NOW()
+WHERE token_hash = $1 AND revoked_at IS NULL
+RETURNING *;
+
+-- name: RevokeApiToken :execrows
+UPDATE api_tokens
+SET revoked_at = NOW()
+WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL;
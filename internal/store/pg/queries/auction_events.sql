@@ -0,0 +1,17 @@
+-- name: AppendAuctionEvent :one
+INSERT INTO auction_events (product_id, seq, type, data)
+SELECT sqlc.arg(product_id), COALESCE(MAX(seq), 0) + 1, sqlc.arg(type), sqlc.arg(data)
+FROM auction_events
+WHERE product_id = sqlc.arg(product_id)
+RETURNING seq;
+
+-- name: ListAuctionEventsAfter :many
+SELECT * FROM auction_events
+WHERE product_id = $1 AND seq > $2 AND seq < sqlc.arg(before)
+ORDER BY seq
+LIMIT sqlc.arg(max_events);
+
+-- name: GetLastAuctionEventSeq :one
+SELECT COALESCE(MAX(seq), 0)::BIGINT
+FROM auction_events
+WHERE product_id = $1;
@@ -0,0 +1,9 @@
+-- name: AcquireAuctionLease :one
+INSERT INTO auction_leases (product_id, instance_id, expires_at)
+VALUES ($1, $2, $3)
+ON CONFLICT (product_id) DO UPDATE SET
+  instance_id = EXCLUDED.instance_id,
+  expires_at = EXCLUDED.expires_at
+WHERE auction_leases.instance_id = EXCLUDED.instance_id
+  OR auction_leases.expires_at < NOW()
+RETURNING instance_id;
@@ -13,3 +13,7 @@ SELECT * FROM bids
 WHERE product_id = $1
 ORDER BY amount DESC
 LIMIT 1;
+
+-- name: ListBidderIDsByProductID :many
+SELECT DISTINCT bidder_id FROM bids
+WHERE product_id = $1;
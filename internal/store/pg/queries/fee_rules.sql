@@ -0,0 +1,45 @@
+-- name: CreateFeeRule :one
+INSERT INTO fee_rules (
+  category,
+  min_price,
+  max_price,
+  kind,
+  rate,
+  amount,
+  cap,
+  tiers,
+  priority
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING *;
+
+-- name: DeleteFeeRule :execrows
+DELETE FROM fee_rules WHERE id = $1;
+
+-- name: ListFeeRules :many
+SELECT * FROM fee_rules
+ORDER BY category NULLS LAST, min_price, priority DESC;
+
+-- name: MatchFeeRule :one
+-- Rules of the category win over the ones for every category, then the ones
+-- with the highest priority and narrowest price band.
+SELECT * FROM fee_rules
+WHERE (category = sqlc.arg(category)::TEXT OR category IS NULL)
+  AND min_price <= sqlc.arg(price)::FLOAT
+  AND (max_price IS NULL OR sqlc.arg(price)::FLOAT < max_price)
+ORDER BY category IS NULL, priority DESC, max_price - min_price NULLS LAST, created_at DESC
+LIMIT 1;
+
+-- name: UpdateFeeRule :one
+UPDATE fee_rules
+SET category = sqlc.arg(category),
+  min_price = sqlc.arg(min_price),
+  max_price = sqlc.arg(max_price),
+  kind = sqlc.arg(kind),
+  rate = sqlc.arg(rate),
+  amount = sqlc.arg(amount),
+  cap = sqlc.arg(cap),
+  tiers = sqlc.arg(tiers),
+  priority = sqlc.arg(priority),
+  updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
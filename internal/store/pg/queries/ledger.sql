@@ -0,0 +1,74 @@
+-- name: CreateLedgerJournal :one
+INSERT INTO ledger_journals (kind, reference_id, description)
+VALUES ($1, $2, $3)
+ON CONFLICT (kind, reference_id) DO NOTHING
+RETURNING *;
+
+-- name: CreateLedgerPosting :exec
+INSERT INTO ledger_postings (journal_id, account_id, amount)
+VALUES ($1, $2, $3);
+
+-- name: GetLedgerAccountBalance :one
+SELECT COALESCE(SUM(amount), 0)::BIGINT AS balance FROM ledger_postings
+WHERE account_id = $1;
+
+-- name: GetLedgerJournal :one
+SELECT * FROM ledger_journals
+WHERE kind = $1 AND reference_id = $2;
+
+-- name: GetLedgerTotals :one
+SELECT
+  COUNT(DISTINCT journal_id) AS journals,
+  COUNT(*) AS postings,
+  COALESCE(SUM(amount), 0)::BIGINT AS total
+FROM ledger_postings;
+
+-- name: ListLedgerBalancesByUserID :many
+SELECT ledger_accounts.kind, COALESCE(SUM(ledger_postings.amount), 0)::BIGINT AS balance
+FROM ledger_accounts
+LEFT JOIN ledger_postings ON ledger_postings.account_id = ledger_accounts.id
+WHERE ledger_accounts.user_id = $1
+GROUP BY ledger_accounts.kind;
+
+-- name: ListLedgerJournalPostings :many
+SELECT ledger_postings.account_id, ledger_accounts.kind AS account_kind, ledger_accounts.user_id AS account_user_id, ledger_postings.amount
+FROM ledger_postings
+JOIN ledger_journals ON ledger_journals.id = ledger_postings.journal_id
+JOIN ledger_accounts ON ledger_accounts.id = ledger_postings.account_id
+WHERE ledger_journals.kind = $1 AND ledger_journals.reference_id = $2;
+
+-- name: ListLedgerPostingsByUserID :many
+SELECT
+  ledger_postings.id,
+  ledger_postings.journal_id,
+  ledger_journals.kind AS journal_kind,
+  ledger_journals.reference_id,
+  ledger_journals.description,
+  ledger_accounts.kind AS account_kind,
+  ledger_postings.amount,
+  ledger_postings.created_at
+FROM ledger_postings
+JOIN ledger_journals ON ledger_journals.id = ledger_postings.journal_id
+JOIN ledger_accounts ON ledger_accounts.id = ledger_postings.account_id
+WHERE ledger_accounts.user_id = $1
+ORDER BY ledger_postings.created_at DESC, ledger_postings.id
+LIMIT $2;
+
+-- name: ListUnbalancedLedgerJournals :many
+SELECT
+  ledger_journals.id,
+  ledger_journals.kind,
+  ledger_journals.reference_id,
+  COUNT(ledger_postings.id) AS postings,
+  COALESCE(SUM(ledger_postings.amount), 0)::BIGINT AS total
+FROM ledger_journals
+LEFT JOIN ledger_postings ON ledger_postings.journal_id = ledger_journals.id
+GROUP BY ledger_journals.id
+HAVING COUNT(ledger_postings.id) < 2 OR COALESCE(SUM(ledger_postings.amount), 0) <> 0
+ORDER BY ledger_journals.created_at;
+
+-- name: UpsertLedgerAccount :one
+INSERT INTO ledger_accounts (kind, user_id)
+VALUES ($1, $2)
+ON CONFLICT (kind, user_id) DO UPDATE SET kind = EXCLUDED.kind
+RETURNING *;
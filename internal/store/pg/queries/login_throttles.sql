@@ -0,0 +1,28 @@
+-- name: ListActiveLoginLocks :many
+SELECT key, locked_until
+FROM login_throttles
+WHERE key = ANY(sqlc.arg(keys)::text[]) AND locked_until > NOW();
+
+-- name: RecordLoginFailure :one
+INSERT INTO login_throttles (key, failures, last_failure_at)
+VALUES (sqlc.arg(key), 1, NOW())
+ON CONFLICT (key) DO UPDATE SET
+  failures = CASE
+    WHEN login_throttles.last_failure_at < sqlc.arg(window_start) THEN 1
+    ELSE login_throttles.failures + 1
+  END,
+  last_failure_at = NOW()
+RETURNING failures;
+
+-- name: LockLoginKey :exec
+UPDATE login_throttles
+SET locked_until = $2
+WHERE key = $1;
+
+-- name: ResetLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE key = $1;
+
+-- name: CreateLoginLockout :exec
+INSERT INTO login_lockouts (key, failures, ip_address, locked_until)
+VALUES ($1, $2, $3, $4);
@@ -0,0 +1,44 @@
+-- name: CreateNotification :one
+INSERT INTO notifications (user_id, kind, event_id, title, body, data)
+VALUES ($1, $2, $3, $4, $5, $6)
+ON CONFLICT (user_id, event_id) DO NOTHING
+RETURNING *;
+
+-- name: ListNotificationPreferences :many
+SELECT kind, in_app, email FROM notification_preferences
+WHERE user_id = $1;
+
+-- name: GetNotificationPreference :one
+SELECT kind, in_app, email FROM notification_preferences
+WHERE user_id = $1 AND kind = $2;
+
+-- name: UpsertNotificationPreference :exec
+INSERT INTO notification_preferences (user_id, kind, in_app, email)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (user_id, kind) DO UPDATE
+SET in_app = EXCLUDED.in_app, email = EXCLUDED.email, updated_at = NOW();
+
+-- name: ListNotifications :many
+SELECT * FROM notifications
+WHERE user_id = sqlc.arg(user_id)
+  AND (NOT sqlc.arg(unread_only)::BOOLEAN OR read_at IS NULL)
+  AND (
+    sqlc.arg(cursor)::uuid = '00000000-0000-0000-0000-000000000000'
+    OR (created_at, id) < (SELECT c.created_at, c.id FROM notifications c WHERE c.id = sqlc.arg(cursor))
+  )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(max_notifications);
+
+-- name: CountUnreadNotifications :one
+SELECT COUNT(*) FROM notifications
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationRead :execrows
+UPDATE notifications
+SET read_at = COALESCE(read_at, NOW())
+WHERE id = $1 AND user_id = $2;
+
+-- name: MarkAllNotificationsRead :execrows
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
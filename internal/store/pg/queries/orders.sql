@@ -0,0 +1,52 @@
+-- name: ClaimOverdueOrders :many
+SELECT * FROM orders
+WHERE status = 'awaiting_payment' AND payment_due_at <= NOW()
+  AND NOT EXISTS (
+    SELECT 1 FROM payments
+    WHERE payments.order_id = orders.id
+      AND payments.status IN ('pending', 'requires_action', 'requires_capture')
+      AND payments.created_at > sqlc.arg(payments_since)
+  )
+ORDER BY payment_due_at
+LIMIT sqlc.arg(max_orders)
+FOR UPDATE SKIP LOCKED;
+
+-- name: CreateOrder :one
+INSERT INTO orders (
+  product_id,
+  seller_id,
+  buyer_id,
+  bid_id,
+  final_price,
+  payment_due_at,
+  fee,
+  net_proceeds,
+  fee_rule_id
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (product_id) WHERE status NOT IN ('cancelled', 'defaulted') DO NOTHING
+RETURNING *;
+
+-- name: GetOrderByID :one
+SELECT * FROM orders WHERE id = $1;
+
+-- name: GetOrderByIDForUpdate :one
+SELECT * FROM orders WHERE id = $1 FOR UPDATE;
+
+-- name: ListOrdersByProductID :many
+SELECT * FROM orders
+WHERE product_id = $1
+ORDER BY created_at;
+
+-- name: ListOrdersByUserID :many
+SELECT * FROM orders
+WHERE (sqlc.arg(as_buyer)::BOOLEAN AND buyer_id = sqlc.arg(user_id))
+  OR (sqlc.arg(as_seller)::BOOLEAN AND seller_id = sqlc.arg(user_id))
+ORDER BY created_at DESC;
+
+-- name: UpdateOrderStatus :one
+UPDATE orders
+SET status = sqlc.arg(status),
+  tracking_number = sqlc.arg(tracking_number),
+  updated_at = NOW()
+WHERE id = sqlc.arg(id)
+RETURNING *;
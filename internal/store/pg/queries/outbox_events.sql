@@ -0,0 +1,28 @@
+-- name: CreateOutboxEvent :exec
+INSERT INTO outbox_events (topic, aggregate_id, payload)
+VALUES ($1, $2, $3);
+
+-- name: ClaimOutboxEvents :many
+UPDATE outbox_events
+SET available_at = sqlc.arg(lease_until)
+WHERE id IN (
+  SELECT id FROM outbox_events
+  WHERE dispatched_at IS NULL AND failed_at IS NULL AND available_at <= NOW()
+  ORDER BY created_at
+  LIMIT sqlc.arg(max_events)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: MarkOutboxEventDispatched :exec
+UPDATE outbox_events
+SET dispatched_at = NOW(), attempts = attempts + 1, last_error = ''
+WHERE id = $1;
+
+-- name: MarkOutboxEventFailed :exec
+UPDATE outbox_events
+SET attempts = attempts + 1,
+  last_error = sqlc.arg(last_error),
+  available_at = sqlc.arg(retry_at),
+  failed_at = CASE WHEN sqlc.arg(give_up)::BOOLEAN THEN NOW() END
+WHERE id = sqlc.arg(id);
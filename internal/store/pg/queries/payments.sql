@@ -0,0 +1,41 @@
+-- name: CreatePayment :one
+INSERT INTO payments (order_id, provider, amount)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: GetPaymentByIDForUpdate :one
+SELECT * FROM payments WHERE id = $1 FOR UPDATE;
+
+-- name: GetPaymentByIntentID :one
+SELECT * FROM payments
+WHERE provider = $1 AND intent_id = $2;
+
+-- name: GetSucceededPaymentByOrderID :one
+SELECT * FROM payments
+WHERE order_id = $1 AND status = 'succeeded';
+
+-- name: ListPaymentsByOrderID :many
+SELECT * FROM payments
+WHERE order_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListRefundPendingPayments :many
+SELECT * FROM payments
+WHERE provider = $1 AND status = 'refund_pending'
+ORDER BY updated_at
+LIMIT $2;
+
+-- name: RecordPaymentEvent :execrows
+INSERT INTO payment_events (provider, event_id, type, payload)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (provider, event_id) DO NOTHING;
+
+-- name: UpdatePayment :one
+UPDATE payments
+SET intent_id = $1,
+  status = $2,
+  next_action_url = $3,
+  failure_reason = $4,
+  updated_at = NOW()
+WHERE id = $5
+RETURNING *;
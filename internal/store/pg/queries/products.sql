@@ -4,8 +4,37 @@ INSERT INTO products (
   name,
   description,
   base_price,
-  auction_end
-) VALUES ($1, $2, $3, $4, $5) RETURNING id;
+  auction_end,
+  category
+) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id;
 
 -- name: GetProductByID :one
 SELECT * FROM products WHERE id = $1;
+
+-- name: GetProductByIDForUpdate :one
+SELECT * FROM products WHERE id = $1 FOR UPDATE;
+
+-- name: ListUnsettledProducts :many
+SELECT id FROM products
+WHERE settled_at IS NULL AND auction_end <= NOW()
+ORDER BY auction_end
+LIMIT $1;
+
+-- name: SettleProduct :exec
+UPDATE products
+SET settled_at = NOW(), is_sold = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: ClaimEndingSoonProducts :many
+UPDATE products
+SET ending_soon_sent_at = NOW()
+WHERE id IN (
+  SELECT id FROM products
+  WHERE ending_soon_sent_at IS NULL
+    AND auction_end > NOW()
+    AND auction_end <= sqlc.arg(ends_before)
+  ORDER BY auction_end
+  LIMIT sqlc.arg(max_products)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING id, seller_id, auction_end;
@@ -0,0 +1,37 @@
+-- name: CreateSecondChanceOffer :one
+INSERT INTO second_chance_offers (order_id, product_id, seller_id, bidder_id, bid_id, amount, expires_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+RETURNING *;
+
+-- name: ExpireSecondChanceOffers :many
+UPDATE second_chance_offers
+SET status = 'expired',
+  responded_at = NOW()
+WHERE id IN (
+  SELECT id FROM second_chance_offers
+  WHERE status = 'pending' AND expires_at <= NOW()
+  ORDER BY expires_at
+  LIMIT sqlc.arg(max_offers)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING *;
+
+-- name: GetSecondChanceOfferByIDForUpdate :one
+SELECT * FROM second_chance_offers WHERE id = $1 FOR UPDATE;
+
+-- name: ListSecondChanceOffersByProductID :many
+SELECT * FROM second_chance_offers
+WHERE product_id = $1
+ORDER BY created_at;
+
+-- name: ListSecondChanceOffersByUserID :many
+SELECT * FROM second_chance_offers
+WHERE bidder_id = $1 OR seller_id = $1
+ORDER BY created_at DESC;
+
+-- name: RespondToSecondChanceOffer :one
+UPDATE second_chance_offers
+SET status = $1,
+  responded_at = NOW()
+WHERE id = $2
+RETURNING *;
@@ -0,0 +1,46 @@
+-- name: CreateUserSession :exec
+INSERT INTO user_sessions (token, user_id, ip_address, user_agent)
+VALUES ($1, $2, $3, $4);
+
+-- name: DeleteStaleUserSessions :exec
+DELETE FROM user_sessions
+WHERE user_id = $1 AND token NOT IN (SELECT token FROM sessions);
+
+-- name: ListUserSessions :many
+SELECT us.id, us.token, us.ip_address, us.user_agent, us.created_at, us.last_seen_at, s.expiry
+FROM user_sessions us
+JOIN sessions s ON s.token = us.token
+WHERE us.user_id = $1 AND s.expiry > NOW()
+ORDER BY us.last_seen_at DESC;
+
+-- name: TouchUserSession :exec
+UPDATE user_sessions
+SET last_seen_at = NOW()
+WHERE token = $1 AND last_seen_at < NOW() - INTERVAL '1 minute';
+
+-- name: RotateUserSessionToken :exec
+UPDATE user_sessions
+SET token = sqlc.arg(new_token)
+WHERE token = sqlc.arg(old_token);
+
+-- name: DeleteUserSessionByToken :exec
+DELETE FROM user_sessions
+WHERE token = $1;
+
+-- name: RevokeUserSession :execrows
+WITH revoked AS (
+  DELETE FROM user_sessions
+  WHERE user_sessions.id = $1 AND user_sessions.user_id = $2
+  RETURNING user_sessions.token
+)
+DELETE FROM sessions
+WHERE sessions.token IN (SELECT token FROM revoked);
+
+-- name: RevokeOtherUserSessions :execrows
+WITH revoked AS (
+  DELETE FROM user_sessions
+  WHERE user_sessions.user_id = $1 AND user_sessions.token <> $2
+  RETURNING user_sessions.token
+)
+DELETE FROM sessions
+WHERE sessions.token IN (SELECT token FROM revoked);
@@ -4,11 +4,31 @@ VALUES ($1, $2, $3, $4)
 RETURNING id;
 
 -- name: GetUserByID :one
-SELECT id, username, email, bio, password_hash, created_at, updated_at
+SELECT id, username, email, bio, password_hash, role, suspended_at, locale, created_at, updated_at
 FROM users
 WHERE id = $1;
 
 -- name: GetUserByEmail :one
-SELECT id, username, email, bio, password_hash, created_at, updated_at
+SELECT id, username, email, bio, password_hash, role, suspended_at, locale, created_at, updated_at
 FROM users
 WHERE email = $1;
+
+-- name: SuspendUser :execrows
+UPDATE users
+SET suspended_at = COALESCE(suspended_at, NOW()), updated_at = NOW()
+WHERE id = $1;
+
+-- name: ReinstateUser :execrows
+UPDATE users
+SET suspended_at = NULL, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateUserLocale :execrows
+UPDATE users
+SET locale = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: UpdateUserPassword :exec
+UPDATE users
+SET password_hash = $2, updated_at = NOW()
+WHERE id = $1;
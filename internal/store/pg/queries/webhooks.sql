@@ -0,0 +1,62 @@
+-- name: CreateWebhook :one
+INSERT INTO webhooks (user_id, url, secret, event_types)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: ListWebhooksByUserID :many
+SELECT id, url, event_types, created_at
+FROM webhooks
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: GetUserWebhook :one
+SELECT * FROM webhooks
+WHERE id = $1 AND user_id = $2;
+
+-- name: DeleteUserWebhook :execrows
+DELETE FROM webhooks
+WHERE id = $1 AND user_id = $2;
+
+-- name: ListWebhooksForEvent :many
+SELECT * FROM webhooks
+WHERE user_id = ANY(sqlc.arg(user_ids)::uuid[]) AND sqlc.arg(topic)::text = ANY(event_types);
+
+-- name: CreateWebhookDelivery :one
+INSERT INTO webhook_deliveries (webhook_id, event_id, topic, payload)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (webhook_id, event_id) DO NOTHING
+RETURNING *;
+
+-- name: ClaimWebhookDeliveries :many
+UPDATE webhook_deliveries d
+SET next_attempt_at = sqlc.arg(lease_until)
+FROM webhooks w
+WHERE w.id = d.webhook_id AND d.id IN (
+  SELECT id FROM webhook_deliveries
+  WHERE status = 'pending' AND next_attempt_at <= NOW()
+  ORDER BY next_attempt_at
+  LIMIT sqlc.arg(max_deliveries)
+  FOR UPDATE SKIP LOCKED
+)
+RETURNING d.id, d.event_id, d.topic, d.payload, d.attempts, d.created_at, w.url, w.secret;
+
+-- name: MarkWebhookDeliverySucceeded :exec
+UPDATE webhook_deliveries
+SET status = 'succeeded', attempts = attempts + 1, response_status = $2, last_error = '', delivered_at = NOW()
+WHERE id = $1;
+
+-- name: MarkWebhookDeliveryFailed :exec
+UPDATE webhook_deliveries
+SET attempts = attempts + 1,
+  response_status = sqlc.arg(response_status),
+  last_error = sqlc.arg(last_error),
+  next_attempt_at = sqlc.arg(retry_at),
+  status = CASE WHEN sqlc.arg(give_up)::BOOLEAN THEN 'failed' ELSE 'pending' END
+WHERE id = sqlc.arg(id);
+
+-- name: ListWebhookDeliveries :many
+SELECT id, event_id, topic, status, attempts, response_status, last_error, next_attempt_at, delivered_at, created_at
+FROM webhook_deliveries
+WHERE webhook_id = $1
+ORDER BY created_at DESC
+LIMIT $2;
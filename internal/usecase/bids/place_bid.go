@@ -0,0 +1,19 @@
+package bids
+
+import (
+	"context"
+
+	"github.com/oThinas/bid/internal/validator"
+)
+
+type PlaceBidRequest struct {
+	Amount float64 `json:"amount"`
+}
+
+func (req PlaceBidRequest) Valid(context.Context) validator.Evaluator {
+	var ev validator.Evaluator
+
+	ev.CheckField(req.Amount > 0, "amount", "amount must be greater than 0")
+
+	return ev
+}
@@ -0,0 +1,89 @@
+package fees
+
+import (
+	"context"
+
+	"github.com/oThinas/bid/internal/services"
+	"github.com/oThinas/bid/internal/validator"
+)
+
+// FeeRuleRequest creates or replaces a fee rule. Only the fields of its kind
+// are used: rate for percentage rules, amount for fixed ones, rate and cap for
+// capped ones and tiers for tiered ones.
+type FeeRuleRequest struct {
+	Category *string            `json:"category"`
+	MinPrice float64            `json:"min_price"`
+	MaxPrice *float64           `json:"max_price"`
+	Kind     string             `json:"kind"`
+	Rate     float64            `json:"rate"`
+	Amount   float64            `json:"amount"`
+	Cap      float64            `json:"cap"`
+	Tiers    []services.FeeTier `json:"tiers"`
+	Priority int32              `json:"priority"`
+}
+
+func (req FeeRuleRequest) Valid(context.Context) validator.Evaluator {
+	var ev validator.Evaluator
+
+	if req.Category != nil {
+		ev.CheckField(validator.MaxChars(*req.Category, 100), "category", "this field must have at most 100 characters")
+	}
+
+	ev.CheckField(req.MinPrice >= 0, "min_price", "min price cannot be negative")
+	if req.MaxPrice != nil {
+		ev.CheckField(*req.MaxPrice > req.MinPrice, "max_price", "max price must be greater than min price")
+	}
+
+	ev.CheckField(
+		validator.PermittedValue(
+			req.Kind,
+			services.FeeKindPercentage,
+			services.FeeKindFixed,
+			services.FeeKindCapped,
+			services.FeeKindTiered,
+		),
+		"kind",
+		"kind must be any of: percentage, fixed, capped, tiered",
+	)
+
+	switch req.Kind {
+	case services.FeeKindPercentage:
+		ev.CheckField(validRate(req.Rate), "rate", "rate must be between 0 and 1")
+	case services.FeeKindFixed:
+		ev.CheckField(req.Amount >= 0, "amount", "amount cannot be negative")
+	case services.FeeKindCapped:
+		ev.CheckField(validRate(req.Rate), "rate", "rate must be between 0 and 1")
+		ev.CheckField(req.Cap > 0, "cap", "cap must be greater than 0")
+	case services.FeeKindTiered:
+		ev.CheckField(len(req.Tiers) > 0, "tiers", "at least one tier is required")
+		ev.CheckField(validTiers(req.Tiers), "tiers", "tiers must have rates between 0 and 1 and increasing up_to, with the last one and only it without up_to")
+	}
+
+	return ev
+}
+
+func validRate(rate float64) bool {
+	return rate >= 0 && rate <= 1
+}
+
+// validTiers reports whether the tiers have valid rates and increasing
+// up_to, the last one having none so that every price falls in a tier.
+func validTiers(tiers []services.FeeTier) bool {
+	var previous float64
+	for i, tier := range tiers {
+		if !validRate(tier.Rate) {
+			return false
+		}
+
+		if i == len(tiers)-1 {
+			return tier.UpTo == nil
+		}
+
+		if tier.UpTo == nil || *tier.UpTo <= previous {
+			return false
+		}
+		previous = *tier.UpTo
+	}
+
+	return true
+}
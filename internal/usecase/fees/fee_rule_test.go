@@ -0,0 +1,70 @@
+package fees
+
+import (
+	"testing"
+
+	"github.com/oThinas/bid/internal/services"
+)
+
+func TestValidTiers(t *testing.T) {
+	upTo := func(value float64) *float64 { return &value }
+
+	tests := []struct {
+		name  string
+		tiers []services.FeeTier
+		valid bool
+	}{
+		{
+			name:  "open-ended tier",
+			tiers: []services.FeeTier{{Rate: 0.05}},
+			valid: true,
+		},
+		{
+			name: "increasing tiers",
+			tiers: []services.FeeTier{
+				{UpTo: upTo(100), Rate: 0.1},
+				{UpTo: upTo(1000), Rate: 0.05},
+				{Rate: 0.02},
+			},
+			valid: true,
+		},
+		{
+			name: "bounded last tier",
+			tiers: []services.FeeTier{
+				{UpTo: upTo(100), Rate: 0.1},
+				{UpTo: upTo(1000), Rate: 0.05},
+			},
+		},
+		{
+			name: "open-ended tier before the last",
+			tiers: []services.FeeTier{
+				{UpTo: upTo(100), Rate: 0.1},
+				{Rate: 0.05},
+				{Rate: 0.02},
+			},
+		},
+		{
+			name: "decreasing up_to",
+			tiers: []services.FeeTier{
+				{UpTo: upTo(1000), Rate: 0.1},
+				{UpTo: upTo(100), Rate: 0.05},
+				{Rate: 0.02},
+			},
+		},
+		{
+			name: "rate above 1",
+			tiers: []services.FeeTier{
+				{UpTo: upTo(100), Rate: 1.5},
+				{Rate: 0.02},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := validTiers(test.tiers); got != test.valid {
+				t.Errorf("validTiers = %v, want %v", got, test.valid)
+			}
+		})
+	}
+}
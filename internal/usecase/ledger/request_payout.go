@@ -0,0 +1,22 @@
+package ledger
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/oThinas/bid/internal/validator"
+)
+
+type RequestPayoutRequest struct {
+	IdempotencyKey uuid.UUID `json:"idempotency_key"`
+	Amount         float64   `json:"amount"`
+}
+
+func (req RequestPayoutRequest) Valid(context.Context) validator.Evaluator {
+	var ev validator.Evaluator
+
+	ev.CheckField(req.IdempotencyKey != uuid.Nil, "idempotency_key", "this field cannot be empty")
+	ev.CheckField(req.Amount > 0, "amount", "amount must be greater than 0")
+
+	return ev
+}
@@ -0,0 +1,21 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/oThinas/bid/internal/validator"
+)
+
+// UpdateLocaleRequest sets the locale of the emails of the user. An empty
+// locale goes back to the default one.
+type UpdateLocaleRequest struct {
+	Locale string `json:"locale"`
+}
+
+func (req UpdateLocaleRequest) Valid(context.Context) validator.Evaluator {
+	var ev validator.Evaluator
+
+	ev.CheckField(validator.MaxChars(req.Locale, 35), "locale", "this field must have at most 35 characters")
+
+	return ev
+}
@@ -0,0 +1,27 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/oThinas/bid/internal/services"
+	"github.com/oThinas/bid/internal/validator"
+)
+
+type UpdatePreferencesRequest struct {
+	Preferences []services.NotificationPreference `json:"preferences"`
+}
+
+func (req UpdatePreferencesRequest) Valid(context.Context) validator.Evaluator {
+	var ev validator.Evaluator
+
+	ev.CheckField(len(req.Preferences) > 0, "preferences", "at least one preference is required")
+	for _, preference := range req.Preferences {
+		ev.CheckField(
+			validator.PermittedValue(preference.Kind, services.NotificationKinds...),
+			"preferences",
+			"kind must be any of: outbid, won, ending_soon, item_sold, payment_due, second_chance_offer",
+		)
+	}
+
+	return ev
+}
@@ -0,0 +1,31 @@
+package orders
+
+import (
+	"context"
+
+	"github.com/oThinas/bid/internal/services"
+	"github.com/oThinas/bid/internal/validator"
+)
+
+type UpdateOrderStatusRequest struct {
+	Status         string `json:"status"`
+	TrackingNumber string `json:"tracking_number"`
+}
+
+func (req UpdateOrderStatusRequest) Valid(context.Context) validator.Evaluator {
+	var ev validator.Evaluator
+
+	ev.CheckField(
+		validator.PermittedValue(
+			req.Status,
+			services.OrderShipped,
+			services.OrderDelivered,
+			services.OrderCancelled,
+		),
+		"status",
+		"status must be any of: shipped, delivered, cancelled",
+	)
+	ev.CheckField(validator.MaxChars(req.TrackingNumber, 100), "tracking_number", "this field must have at most 100 characters")
+
+	return ev
+}
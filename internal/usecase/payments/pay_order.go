@@ -0,0 +1,20 @@
+package payments
+
+import (
+	"context"
+
+	"github.com/oThinas/bid/internal/validator"
+)
+
+type PayOrderRequest struct {
+	PaymentMethod string `json:"payment_method"`
+}
+
+func (req PayOrderRequest) Valid(context.Context) validator.Evaluator {
+	var ev validator.Evaluator
+
+	ev.CheckField(validator.NotBlank(req.PaymentMethod), "payment_method", "this field cannot be empty")
+	ev.CheckField(validator.MaxChars(req.PaymentMethod, 100), "payment_method", "this field must have at most 100 characters")
+
+	return ev
+}
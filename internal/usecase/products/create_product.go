@@ -14,6 +14,7 @@ type CreateProductRequest struct {
 	Description string    `json:"description"`
 	BasePrice   float64   `json:"base_price"`
 	AuctionEnd  time.Time `json:"auction_end"`
+	Category    string    `json:"category"`
 }
 
 const minAuctionDuration = 2 * time.Hour
@@ -30,6 +31,8 @@ func (req CreateProductRequest) Valid(context.Context) validator.Evaluator {
 		"this field must have between 10 and 255 characters",
 	)
 
+	ev.CheckField(validator.MaxChars(req.Category, 100), "category", "this field must have at most 100 characters")
+
 	ev.CheckField(req.BasePrice > 0, "base_price", "base price must be greater than 0")
 	ev.CheckField(time.Until(req.AuctionEnd) >= minAuctionDuration, "auction_end", "this field must be at least 2 hours from now")
 
@@ -0,0 +1,31 @@
+package tokens
+
+import (
+	"context"
+
+	"github.com/oThinas/bid/internal/services"
+	"github.com/oThinas/bid/internal/validator"
+)
+
+type CreateTokenRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+func (req CreateTokenRequest) Valid(context.Context) validator.Evaluator {
+	var ev validator.Evaluator
+
+	ev.CheckField(validator.NotBlank(req.Name), "name", "this field cannot be empty")
+	ev.CheckField(validator.MaxChars(req.Name, 50), "name", "this field must have at most 50 characters")
+
+	ev.CheckField(len(req.Scopes) > 0, "scopes", "at least one scope is required")
+	for _, scope := range req.Scopes {
+		ev.CheckField(
+			validator.PermittedValue(scope, services.ApiTokenScopes...),
+			"scopes",
+			"scopes must be any of: auctions:read, bids:write, products:write",
+		)
+	}
+
+	return ev
+}
@@ -0,0 +1,21 @@
+package users
+
+import (
+	"context"
+
+	"github.com/oThinas/bid/internal/validator"
+)
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+func (req ChangePasswordRequest) Valid(context.Context) validator.Evaluator {
+	var ev validator.Evaluator
+
+	ev.CheckField(validator.NotBlank(req.CurrentPassword), "current_password", "this field cannot be empty")
+	ev.CheckField(validator.MinChars(req.NewPassword, 8), "new_password", "this field must have at least 8 characters")
+
+	return ev
+}
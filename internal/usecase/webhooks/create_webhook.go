@@ -0,0 +1,45 @@
+package webhooks
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/oThinas/bid/internal/services"
+	"github.com/oThinas/bid/internal/validator"
+)
+
+type CreateWebhookRequest struct {
+	URL        string   `json:"url"`
+	EventTypes []string `json:"event_types"`
+}
+
+func (req CreateWebhookRequest) Valid(ctx context.Context) validator.Evaluator {
+	var ev validator.Evaluator
+
+	ev.CheckField(validator.NotBlank(req.URL), "url", "this field cannot be empty")
+	ev.CheckField(validator.MaxChars(req.URL, 2048), "url", "this field must have at most 2048 characters")
+	ev.CheckField(isWebURL(req.URL), "url", "this field must be an absolute http or https url")
+	if isWebURL(req.URL) {
+		ev.CheckField(services.CheckWebhookURL(ctx, req.URL) == nil, "url", "this field must be a url resolving to a public address")
+	}
+
+	ev.CheckField(len(req.EventTypes) > 0, "event_types", "at least one event type is required")
+	for _, eventType := range req.EventTypes {
+		ev.CheckField(
+			validator.PermittedValue(eventType, services.WebhookTopics...),
+			"event_types",
+			"event types must be any of: bid.placed, bid.outbid, auction.ending_soon, auction.ended, item.sold, order.created, order.status_changed, second_chance.offered, second_chance.accepted, second_chance.declined, second_chance.expired",
+		)
+	}
+
+	return ev
+}
+
+func isWebURL(value string) bool {
+	u, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}